@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// localRepoNames returns the names of the repos in the workspace, e.g.
+// github.com/org/repo, sorted
+func localRepoNames() []string {
+	baseDir := getWorkspaceDir()
+	if !dirExists(baseDir) {
+		return nil
+	}
+	names := []string{}
+	forEachGitDirIn(baseDir, func(relativeDir string) {
+		names = append(names, filepath.ToSlash(relativeDir))
+	})
+	slices.Sort(names)
+	return names
+}
+
+// knownRepoNames returns the names of the repos in the workspace and in the
+// listings cached by 'orgit sync'
+func knownRepoNames() []string {
+	names := localRepoNames()
+	for _, r := range readCachedRemoteRepos() {
+		names = append(names, r.RepoName.String())
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// completeRepoPath completes toComplete from names one path element at a
+// time, so hosts and orgs are suggested before the repos in them. With
+// orgsOnly, only the hosts and orgs are suggested.
+func completeRepoPath(toComplete string, names []string, orgsOnly bool) ([]string, cobra.ShellCompDirective) {
+	completions := []string{}
+	partial := false
+	for _, name := range names {
+		rest, ok := strings.CutPrefix(name, toComplete)
+		if !ok {
+			continue
+		}
+		if i := strings.Index(rest, "/"); i >= 0 {
+			completions = append(completions, toComplete+rest[:i+1])
+			partial = true
+		} else if !orgsOnly {
+			completions = append(completions, name)
+		}
+	}
+	slices.Sort(completions)
+	completions = slices.Compact(completions)
+
+	directive := cobra.ShellCompDirectiveNoFileComp
+	if partial {
+		directive |= cobra.ShellCompDirectiveNoSpace
+	}
+	return completions, directive
+}
+
+type completionFunc = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// completeRepoArgs completes any number of repo arguments from names
+func completeRepoArgs(names func() []string, orgsOnly bool) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return completeRepoPath(toComplete, names(), orgsOnly)
+	}
+}
+
+// completeFirstRepoArg completes the first argument from names
+func completeFirstRepoArg(names func() []string, orgsOnly bool) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return completeRepoPath(toComplete, names(), orgsOnly)
+	}
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func TestCompleteRepoPath(t *testing.T) {
+	names := []string{
+		"github.com/org/api",
+		"github.com/org/web",
+		"github.com/other/tool",
+		"gitlab.com/group/sub/lib",
+	}
+	tests := []struct {
+		toComplete string
+		orgsOnly   bool
+		want       []string
+		noSpace    bool
+	}{
+		{"", false, []string{"github.com/", "gitlab.com/"}, true},
+		{"github.com/", false, []string{"github.com/org/", "github.com/other/"}, true},
+		{"github.com/org/", false, []string{"github.com/org/api", "github.com/org/web"}, false},
+		{"github.com/org/", true, []string{}, false},
+		{"gitlab.com/", true, []string{"gitlab.com/group/"}, true},
+		{"gitlab.com/group/", true, []string{"gitlab.com/group/sub/"}, true},
+		{"bitbucket.org/", false, []string{}, false},
+	}
+	for _, tt := range tests {
+		got, directive := completeRepoPath(tt.toComplete, names, tt.orgsOnly)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("completeRepoPath(%q, %v) = %v, want %v", tt.toComplete, tt.orgsOnly, got, tt.want)
+		}
+		if noSpace := directive&cobra.ShellCompDirectiveNoSpace != 0; noSpace != tt.noSpace {
+			t.Errorf("completeRepoPath(%q, %v) NoSpace = %v, want %v", tt.toComplete, tt.orgsOnly, noSpace, tt.noSpace)
+		}
+	}
+}
+
+func TestRemoteRepoListingCache(t *testing.T) {
+	workspace := t.TempDir()
+	t.Setenv("ORGIT_WORKSPACE", workspace)
+	err := os.MkdirAll(filepath.Join(workspace, "example.org/org/local", ".git"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if repos := readCachedRemoteRepos(); len(repos) != 0 {
+		t.Errorf("expected no cached repos, got %v", repos)
+	}
+
+	api := RemoteRepo{RepoName: MustParseRepoName("example.org/org/api"), CloneUrl: "https://example.org/org/api.git", DefaultBranch: "main", Description: "The API"}
+	old := RemoteRepo{RepoName: MustParseRepoName("example.org/org/old"), CloneUrl: "https://example.org/org/old.git", IsArchived: true}
+	now := time.Now()
+	err = writeRemoteRepoListing(MustParseRepoName("example.org/org"), []RemoteRepo{old, api}, now.Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a more recent listing of a subgroup wins over the same repo in its parent
+	sub := RemoteRepo{RepoName: MustParseRepoName("example.org/org/sub/lib"), CloneUrl: "https://example.org/org/sub/lib.git"}
+	renamed := api
+	renamed.Description = "The new API"
+	err = writeRemoteRepoListing(MustParseRepoName("example.org/org/sub"), []RemoteRepo{sub, renamed}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := readCachedRemoteRepos()
+	want := []RemoteRepo{renamed, old, sub}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	names := knownRepoNames()
+	wantNames := []string{"example.org/org/api", "example.org/org/local", "example.org/org/old", "example.org/org/sub/lib"}
+	if !slices.Equal(names, wantNames) {
+		t.Errorf("got %v, want %v", names, wantNames)
+	}
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const workspaceConfigFile = ".orgitconfig"
+
+func getWorkspaceConfigFile() string {
+	return filepath.Join(getWorkspaceDir(), workspaceConfigFile)
+}
+
+// readSubsectionConfig reads the value of key in each subsection of section
+// from the workspace config, which uses the git config format, e.g.
+//
+//	[target "github.com/org"]
+//		strategy = ff-current
+//
+// returns a map of "github.com/org" to "ff-current" for section "target" and
+// key "strategy"
+func readSubsectionConfig(section, key string) (map[string]string, error) {
+	values := map[string]string{}
+	if !fileExists(getWorkspaceConfigFile()) {
+		return values, nil
+	}
+
+	pattern := fmt.Sprintf(`^%s\..*\.%s$`, section, key)
+	lines, err := doExecQuietLines(getWorkspaceDir(), fmt.Sprintf("git config --file %s --get-regexp %s", shellQuote(getWorkspaceConfigFile()), shellQuote(pattern)))
+	exitErr := &exec.ExitError{}
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return values, nil // no matching keys
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read %s: %w", workspaceConfigFile, err)
+	}
+
+	for _, line := range lines {
+		name, value, _ := strings.Cut(line, " ")
+		subsection := strings.TrimPrefix(name, section+".")
+		subsection = strings.TrimSuffix(subsection, "."+key)
+		values[subsection] = value
+	}
+	return values, nil
+}
+
+// matchTarget returns the value for the most specific target containing
+// repoPath, where targets are paths like "github.com/org" or "gitlab.com/group/subgroup"
+func matchTarget(targets map[string]string, repoPath string) (string, bool) {
+	_, value, found := matchTargetWithPath(targets, repoPath)
+	return value, found
+}
+
+// matchTargetWithPath is matchTarget that also returns the path of the
+// matching target
+func matchTargetWithPath(targets map[string]string, repoPath string) (string, string, bool) {
+	bestTarget := ""
+	bestValue := ""
+	found := false
+	for target, value := range targets {
+		t := strings.Trim(strings.TrimPrefix(target, "https://"), "/")
+		if repoPath != t && !strings.HasPrefix(repoPath, t+"/") {
+			continue
+		}
+		if !found || len(t) > len(bestTarget) {
+			bestTarget, bestValue, found = t, value, true
+		}
+	}
+	return bestTarget, bestValue, found
+}
@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mtibben/orgit/syncprinter"
+)
+
+const dashboardMaxRows = 10
+const dashboardRefreshInterval = 500 * time.Millisecond
+
+const ansiCursorUpFmt = "\033[%dA"
+const ansiClearToEndOfScreen = "\033[J"
+
+// dashboard renders the repos currently being synced above the progress line,
+// slowest first, along with the git phase each repo is in
+type dashboard struct {
+	mu            sync.Mutex
+	inflight      map[string]*inflightRepo
+	renderedLines int
+	stopTicker    chan bool
+	tickerDone    chan bool
+	stopped       bool // the ticker can't be restarted once stopped
+	collapsed     bool // nothing more is rendered once collapsed
+}
+
+type inflightRepo struct {
+	name   string
+	start  time.Time
+	phase  string
+	detail string
+}
+
+func newDashboard() *dashboard {
+	return &dashboard{
+		inflight: map[string]*inflightRepo{},
+	}
+}
+
+func (d *dashboard) start(localDir string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.inflight[localDir] = &inflightRepo{
+		name:  relativeToWorkspace(localDir),
+		start: time.Now(),
+	}
+}
+
+func (d *dashboard) finish(localDir string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.inflight, localDir)
+}
+
+func (d *dashboard) setPhase(localDir, phase string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if r, ok := d.inflight[localDir]; ok {
+		r.phase = phase
+		r.detail = ""
+	}
+}
+
+func (d *dashboard) setDetail(localDir, detail string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if r, ok := d.inflight[localDir]; ok {
+		r.detail = detail
+	}
+}
+
+// rows returns the in-flight repos sorted with the longest running first
+func (d *dashboard) rows() []inflightRepo {
+	rows := make([]inflightRepo, 0, len(d.inflight))
+	for _, r := range d.inflight {
+		rows = append(rows, *r)
+	}
+	slices.SortFunc(rows, func(a, b inflightRepo) int {
+		if c := a.start.Compare(b.start); c != 0 {
+			return c
+		}
+		return strings.Compare(a.name, b.name)
+	})
+	return rows
+}
+
+// eraseStr returns the ANSI sequence that clears everything previously rendered
+func (d *dashboard) eraseStr() string {
+	s := "\r"
+	if d.renderedLines > 0 {
+		s += fmt.Sprintf(ansiCursorUpFmt, d.renderedLines)
+	}
+	return s + ansiClearToEndOfScreen
+}
+
+// render redraws the in-flight table followed by progressLine, printing
+// above first so that it scrolls up out of the dashboard. The cursor is left
+// at the end of progressLine so the next render can erase it.
+func (d *dashboard) render(printer *syncprinter.Printer, above, progressLine string, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.collapsed {
+		if above != "" {
+			printer.Print(above)
+		}
+		return
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString(d.eraseStr())
+	sb.WriteString(above)
+
+	rows := d.rows()
+	shown := rows
+	if len(shown) > dashboardMaxRows {
+		shown = shown[:dashboardMaxRows]
+	}
+
+	nameWidth := 0
+	for _, r := range shown {
+		nameWidth = max(nameWidth, len(r.name))
+	}
+
+	lines := 0
+	for _, r := range shown {
+		phase := r.phase
+		if r.detail != "" {
+			phase = fmt.Sprintf("%s: %s", r.phase, r.detail)
+		}
+		fmt.Fprintf(&sb, "%7s  %-*s  %s\n", formatElapsed(now.Sub(r.start)), nameWidth, r.name, phase)
+		lines++
+	}
+	if len(rows) > len(shown) {
+		fmt.Fprintf(&sb, "         ... and %d more\n", len(rows)-len(shown))
+		lines++
+	}
+
+	sb.WriteString(progressLine)
+	d.renderedLines = lines
+
+	printer.Print(sb.String())
+}
+
+// collapse removes the in-flight table, leaving only progressLine
+func (d *dashboard) collapse(printer *syncprinter.Printer, progressLine string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	printer.Print(d.eraseStr() + progressLine)
+	d.renderedLines = 0
+	d.collapsed = true
+}
+
+func (d *dashboard) startTicker(refresh func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.stopped || d.stopTicker != nil {
+		return
+	}
+	d.stopTicker = make(chan bool)
+	d.tickerDone = make(chan bool)
+	go func(stop, done chan bool) {
+		defer close(done)
+		ticker := time.NewTicker(dashboardRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				refresh()
+			}
+		}
+	}(d.stopTicker, d.tickerDone)
+}
+
+// stop stops the ticker for good, waiting for a refresh in progress to
+// finish so that it can't render after the dashboard is collapsed
+func (d *dashboard) stop() {
+	d.mu.Lock()
+	d.stopped = true
+	stop, done := d.stopTicker, d.tickerDone
+	d.stopTicker, d.tickerDone = nil, nil
+	d.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+func formatElapsed(d time.Duration) string {
+	d = d.Truncate(time.Second)
+	if d < time.Minute {
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+	return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+// gitPhase returns the git subcommand being run by shCmd, e.g. "fetch"
+func gitPhase(shCmd string) string {
+	fields := strings.Fields(shCmd)
+	if len(fields) >= 2 && fields[0] == "git" {
+		return fields[1]
+	}
+	if len(fields) >= 1 {
+		return fields[0]
+	}
+	return ""
+}
+
+var gitProgressRe = regexp.MustCompile(`(Counting objects|Compressing objects|Receiving objects|Resolving deltas|Updating files):\s+(\d+%)`)
+
+// gitProgressWriter scans git's --progress output and reports the latest
+// progress message, e.g. "receiving objects 45%"
+type gitProgressWriter struct {
+	onProgress func(detail string)
+	buf        []byte
+}
+
+func (w *gitProgressWriter) Write(b []byte) (int, error) {
+	w.buf = append(w.buf, b...)
+
+	// only keep the trailing partial line for the next write
+	lastBreak := strings.LastIndexAny(string(w.buf), "\r\n")
+	if lastBreak == -1 {
+		return len(b), nil
+	}
+	complete := string(w.buf[:lastBreak])
+	w.buf = w.buf[lastBreak+1:]
+
+	matches := gitProgressRe.FindAllStringSubmatch(complete, -1)
+	if len(matches) > 0 {
+		m := matches[len(matches)-1]
+		w.onProgress(fmt.Sprintf("%s %s", strings.ToLower(m[1]), m[2]))
+	}
+
+	return len(b), nil
+}
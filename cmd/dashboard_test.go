@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mtibben/orgit/syncprinter"
+)
+
+func TestGitProgressWriter(t *testing.T) {
+	var details []string
+	w := &gitProgressWriter{
+		onProgress: func(detail string) { details = append(details, detail) },
+	}
+
+	writes := []string{
+		"Cloning into 'repo'...\n",
+		"Receiving objects:  10% (1/10)\r",
+		"Receiving objects:  4",
+		"5% (4/10)\rReceiving",
+		" objects: 100% (10/10), done.\n",
+		"Resolving deltas:  50% (1/2)\r",
+	}
+	for _, s := range writes {
+		n, err := w.Write([]byte(s))
+		if err != nil || n != len(s) {
+			t.Fatalf("Write(%q) = %d, %v", s, n, err)
+		}
+	}
+
+	expected := []string{
+		"receiving objects 10%",
+		"receiving objects 45%",
+		"receiving objects 100%",
+		"resolving deltas 50%",
+	}
+	if strings.Join(details, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, details)
+	}
+}
+
+func TestGitPhase(t *testing.T) {
+	tests := map[string]string{
+		"git fetch --progress origin":        "fetch",
+		"git merge --ff-only @{u}":           "merge",
+		`git stash push --message "orgit"`:   "stash",
+		"git clone --recursive https://x /y": "clone",
+		"":                                   "",
+	}
+	for cmd, expected := range tests {
+		if got := gitPhase(cmd); got != expected {
+			t.Errorf("gitPhase(%q) = %q, expected %q", cmd, got, expected)
+		}
+	}
+}
+
+func TestDashboardRenderSlowestFirst(t *testing.T) {
+	t.Setenv("ORGIT_WORKSPACE", "/ws")
+
+	now := time.Now()
+	d := newDashboard()
+	d.inflight["/ws/github.com/org/fast"] = &inflightRepo{name: "github.com/org/fast", start: now.Add(-2 * time.Second), phase: "fetch"}
+	d.inflight["/ws/github.com/org/slow"] = &inflightRepo{name: "github.com/org/slow", start: now.Add(-75 * time.Second), phase: "clone", detail: "receiving objects 45%"}
+
+	buf := &bytes.Buffer{}
+	d.render(syncprinter.NewPrinter(buf), "", "Syncing repos... 1/3", now)
+
+	lines := strings.Split(buf.String(), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %q", buf.String())
+	}
+	if !strings.Contains(lines[0], "1m15s") || !strings.Contains(lines[0], "github.com/org/slow") || !strings.Contains(lines[0], "clone: receiving objects 45%") {
+		t.Errorf("Expected slowest repo first, got %q", lines[0])
+	}
+	if !strings.Contains(lines[1], "2s") || !strings.Contains(lines[1], "github.com/org/fast") {
+		t.Errorf("Expected fastest repo second, got %q", lines[1])
+	}
+	if lines[2] != "Syncing repos... 1/3" {
+		t.Errorf("Expected progress line last, got %q", lines[2])
+	}
+	if d.renderedLines != 2 {
+		t.Errorf("Expected 2 rendered lines, got %d", d.renderedLines)
+	}
+}
+
+func TestDashboardEndProgressLineWhileRefreshing(t *testing.T) {
+	t.Setenv("ORGIT_WORKSPACE", "/ws")
+
+	buf := &bytes.Buffer{}
+	p := &ProgressLogger{Printer: syncprinter.NewPrinter(buf)}
+	p.LogRealtimeProgress.Store(true)
+	p.EnableDashboard()
+	p.EventStartRepo("/ws/github.com/org/repo")
+	p.AddTotalToProgress(1)
+
+	// refresh from other goroutines the way the dashboard ticker does, so
+	// that go test -race catches unsynchronised state
+	done := make(chan bool)
+	for i := 0; i < 4; i++ {
+		go func() {
+			for j := 0; j < 50; j++ {
+				p.PrintProgressLine()
+			}
+			done <- true
+		}()
+	}
+	p.EventClonedRepo("/ws/github.com/org/repo")
+	p.EndProgressLine("done")
+	for i := 0; i < 4; i++ {
+		<-done
+	}
+
+	if p.LogRealtimeProgress.Load() {
+		t.Error("Expected realtime progress to be disabled after EndProgressLine")
+	}
+
+	// nothing renders after the dashboard collapses into the summary line
+	out := buf.String()
+	p.PrintProgressLine()
+	p.dashboard.render(p.Printer, "", "Syncing repos... 1/1", time.Now())
+	if buf.String() != out {
+		t.Errorf("Expected nothing to render after EndProgressLine, got %q", strings.TrimPrefix(buf.String(), out))
+	}
+	summary := "Syncing repos... 1/1 done\n"
+	if strings.Count(out, summary) != 1 || !strings.HasSuffix(out, ansiClearToEndOfScreen+summary) {
+		t.Errorf("Expected the summary line once, last and with nothing rendered after it, got %q", out)
+	}
+}
+
+func TestDashboardStopWaitsForTicker(t *testing.T) {
+	d := newDashboard()
+	refreshing := make(chan bool)
+	refreshed := atomic.Bool{}
+	d.startTicker(func() {
+		refreshing <- true
+		time.Sleep(50 * time.Millisecond)
+		refreshed.Store(true)
+	})
+	<-refreshing
+	d.stop()
+	if !refreshed.Load() {
+		t.Error("Expected stop to wait for the refresh in progress")
+	}
+
+	d.startTicker(func() { t.Error("Expected the ticker not to restart after stop") })
+	if d.stopTicker != nil {
+		t.Error("Expected the ticker not to restart after stop")
+	}
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// divergedPolicyWarn leaves a diverged branch alone
+	divergedPolicyWarn = "warn"
+	// divergedPolicyBackupReset keeps the local branch in a backup branch and resets to the upstream
+	divergedPolicyBackupReset = "backup-reset"
+	// divergedPolicyRebase rebases local commits onto the upstream
+	divergedPolicyRebase = "rebase"
+)
+
+var divergedPolicies = []string{divergedPolicyWarn, divergedPolicyBackupReset, divergedPolicyRebase}
+
+func validateDivergedPolicy(s string) error {
+	if !slices.Contains(divergedPolicies, s) {
+		return fmt.Errorf("unknown diverged policy '%s', must be one of %s", s, strings.Join(divergedPolicies, ", "))
+	}
+	return nil
+}
+
+const backupBranchPrefix = "orgit/backup/"
+
+const (
+	divergedLocalCommits = "local commits ahead"
+	divergedForcePushed  = "upstream was force-pushed"
+	divergedRewritten    = "history rewritten"
+)
+
+// divergence describes how a branch and its upstream have diverged
+type divergence struct {
+	Kind         string
+	Ahead        int
+	Behind       int
+	LocalCommits int // commits that were never on the upstream and have no equivalent there
+}
+
+func (d divergence) String() string {
+	s := fmt.Sprintf("%s, %d ahead and %d behind", d.Kind, d.Ahead, d.Behind)
+	if d.LocalCommits > 0 {
+		s += fmt.Sprintf(", %d local %s", d.LocalCommits, pluralise(d.LocalCommits, "commit", "commits"))
+	}
+	return s
+}
+
+func (c *getCmdContext) countCommits(revs string) (int, error) {
+	out, err := c.doExec(`git rev-list --count ` + revs)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(out)
+}
+
+// checkDivergence returns nil if HEAD can be fast-forwarded to its upstream,
+// otherwise it classifies how they diverged. Whether the upstream was
+// force-pushed is found from the reflog of the remote-tracking branch, which
+// still holds its position before the last fetch.
+func (c *getCmdContext) checkDivergence() (*divergence, error) {
+	counts, err := c.doExec(`git rev-list --left-right --count HEAD...@{u}`)
+	if err != nil {
+		return nil, fmt.Errorf("error comparing with upstream: %w", err)
+	}
+	d := divergence{}
+	_, err = fmt.Sscanf(counts, "%d %d", &d.Ahead, &d.Behind)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing '%s': %w", counts, err)
+	}
+	if d.Ahead == 0 || d.Behind == 0 {
+		return nil, nil
+	}
+
+	localRevs := `--left-only --cherry-pick HEAD...@{u}`
+	forcePushed := false
+	previousUpstream, _ := c.doExec(`git rev-parse --quiet --verify @{u}@{1}`)
+	if previousUpstream != "" {
+		_, err = c.doExec(fmt.Sprintf(`git merge-base --is-ancestor %s @{u}`, previousUpstream))
+		forcePushed = err != nil
+		localRevs += ` --not ` + previousUpstream
+	}
+	d.LocalCommits, err = c.countCommits(localRevs)
+	if err != nil {
+		return nil, fmt.Errorf("error counting local commits: %w", err)
+	}
+
+	switch {
+	case forcePushed:
+		d.Kind = divergedForcePushed
+	case d.LocalCommits == 0:
+		d.Kind = divergedRewritten
+	default:
+		d.Kind = divergedLocalCommits
+	}
+	return &d, nil
+}
+
+// mergeUpstream fast-forwards the current branch to its upstream, applying
+// the diverged policy if it can't be fast-forwarded. DivergedSkipped is set if
+// the policy leaves the branch as it is.
+func (c *getCmdContext) mergeUpstream(branch string) error {
+	d, err := c.checkDivergence()
+	if err != nil {
+		return err
+	}
+	if d == nil {
+		err = c.echoEval(`git merge --ff-only @{u}`)
+		if err != nil {
+			return fmt.Errorf("error fast-forwarding branch '%s': %w", branch, err)
+		}
+		return nil
+	}
+
+	switch c.Diverged {
+	case divergedPolicyBackupReset:
+		// updating the current branch doesn't stash, and a hard reset would
+		// discard the uncommitted changes
+		status, err := c.doExec(`git status --porcelain`)
+		if err != nil {
+			return fmt.Errorf("error checking for uncommitted changes: %w", err)
+		}
+		if status != "" {
+			c.info(fmt.Sprintf("Not resetting '%s', branch '%s' has diverged from its upstream (%s) and there are uncommitted changes", c.WorkingDir, branch, d))
+			c.DivergedSkipped = true
+			return nil
+		}
+		backup := c.backupBranchName(time.Now())
+		err = c.echoEvalf(`git branch %s`, shellQuote(backup))
+		if err != nil {
+			return fmt.Errorf("error backing up diverged branch '%s': %w", branch, err)
+		}
+		err = c.echoEval(`git reset --hard @{u}`)
+		if err != nil {
+			return fmt.Errorf("error resetting diverged branch '%s': %w", branch, err)
+		}
+		c.info(fmt.Sprintf("Reset diverged branch '%s' in '%s' to its upstream (%s), backed up to '%s'", branch, c.WorkingDir, d, backup))
+	case divergedPolicyRebase:
+		// --fork-point skips commits that were removed from a force-pushed upstream
+		err = c.echoEval(`git rebase --fork-point @{u}`)
+		if err != nil {
+			_, _ = c.doExec(`git rebase --abort`)
+			return fmt.Errorf("error rebasing diverged branch '%s' (%s), the rebase was aborted: %w", branch, d, err)
+		}
+		c.info(fmt.Sprintf("Rebased diverged branch '%s' in '%s' onto its upstream (%s)", branch, c.WorkingDir, d))
+	default:
+		c.info(fmt.Sprintf("Not updating '%s', branch '%s' has diverged from its upstream (%s)", c.WorkingDir, branch, d))
+		c.DivergedSkipped = true
+	}
+	return nil
+}
+
+// backupBranchName returns a name for a backup branch made on the date of now,
+// e.g. orgit/backup/2026-10-18, numbering it if that day already has one
+func (c *getCmdContext) backupBranchName(now time.Time) string {
+	date := backupBranchPrefix + now.Format(time.DateOnly)
+	name := date
+	for i := 2; c.branchExists(name); i++ {
+		name = fmt.Sprintf("%s-%d", date, i)
+	}
+	return name
+}
@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newDivergedTestRepo returns a repo whose main branch can't be fast-forwarded
+// to origin/main, diverged in the way described by kind
+func newDivergedTestRepo(t *testing.T, kind string) (*getCmdContext, *url.URL) {
+	t.Helper()
+	remote, local := newTestRepoWithRemote(t)
+	other := filepath.Join(t.TempDir(), "other")
+	gitInTest(t, filepath.Dir(other), "clone", "--quiet", remote, other)
+
+	commit := func(dir, file string) {
+		writeTestFile(t, filepath.Join(dir, file), file)
+		gitInTest(t, dir, "add", file)
+		gitInTest(t, dir, "commit", "--quiet", "-m", file)
+	}
+
+	switch kind {
+	case divergedLocalCommits:
+		commit(local, "local")
+		commit(other, "upstream")
+		gitInTest(t, other, "push", "--quiet", "origin", "main")
+	case divergedForcePushed:
+		commit(other, "dropped")
+		gitInTest(t, other, "push", "--quiet", "origin", "main")
+		gitInTest(t, local, "pull", "--quiet", "--ff-only")
+		gitInTest(t, other, "reset", "--quiet", "--hard", "HEAD~1")
+		commit(other, "replacement")
+		gitInTest(t, other, "push", "--quiet", "--force", "origin", "main")
+	case divergedRewritten:
+		commit(local, "same")
+		commit(other, "upstream")
+		commit(other, "same")
+		gitInTest(t, other, "push", "--quiet", "origin", "main")
+	}
+
+	remoteUrl, _ := url.Parse(remote)
+	return &getCmdContext{
+		Stdout:      io.Discard,
+		Stderr:      io.Discard,
+		CmdEchoFunc: func(cmd, dir string) {},
+		InfoFunc:    func(msg string) {},
+		WorkingDir:  local,
+	}, remoteUrl
+}
+
+func TestCheckDivergence(t *testing.T) {
+	for _, kind := range []string{divergedLocalCommits, divergedForcePushed, divergedRewritten} {
+		c, _ := newDivergedTestRepo(t, kind)
+		gitInTest(t, c.WorkingDir, "fetch", "--quiet")
+
+		d, err := c.checkDivergence()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d == nil {
+			t.Errorf("%s: expected divergence", kind)
+			continue
+		}
+		if d.Kind != kind {
+			t.Errorf("expected divergence '%s', got '%s'", kind, d)
+		}
+		if expected := kind == divergedLocalCommits; (d.LocalCommits > 0) != expected {
+			t.Errorf("%s: unexpected local commits in '%s'", kind, d)
+		}
+	}
+}
+
+func TestDivergedPolicies(t *testing.T) {
+	head := func(c *getCmdContext, rev string) string {
+		out, err := c.doExec("git rev-parse " + rev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	t.Run(divergedPolicyWarn, func(t *testing.T) {
+		c, remoteUrl := newDivergedTestRepo(t, divergedLocalCommits)
+		before := head(c, "HEAD")
+		var warning string
+		c.InfoFunc = func(msg string) { warning = msg }
+		if err := c.doUpdate(remoteUrl, "main"); err != nil {
+			t.Fatal(err)
+		}
+		if head(c, "HEAD") != before {
+			t.Error("expected diverged branch to be left alone")
+		}
+		if !strings.Contains(warning, divergedLocalCommits) {
+			t.Errorf("expected a warning about the divergence, got '%s'", warning)
+		}
+		if !c.DivergedSkipped {
+			t.Error("expected the repo to be reported as not updated")
+		}
+	})
+
+	t.Run(divergedPolicyBackupReset, func(t *testing.T) {
+		c, remoteUrl := newDivergedTestRepo(t, divergedLocalCommits)
+		c.Diverged = divergedPolicyBackupReset
+		before := head(c, "HEAD")
+		if err := c.doUpdate(remoteUrl, "main"); err != nil {
+			t.Fatal(err)
+		}
+		if head(c, "HEAD") != head(c, "origin/main") {
+			t.Error("expected branch to be reset to origin/main")
+		}
+		backups, _ := c.doExec("git for-each-ref --format='%(objectname)' refs/heads/" + backupBranchPrefix)
+		if backups != before {
+			t.Errorf("expected a backup branch at %s, got '%s'", before, backups)
+		}
+		if c.DivergedSkipped {
+			t.Error("expected the repo to be reported as updated")
+		}
+	})
+
+	t.Run(divergedPolicyBackupReset+" dirty", func(t *testing.T) {
+		c, remoteUrl := newDivergedTestRepo(t, divergedLocalCommits)
+		c.Diverged = divergedPolicyBackupReset
+		c.Strategy = updateStrategyFFCurrent
+		before := head(c, "HEAD")
+		writeTestFile(t, filepath.Join(c.WorkingDir, "local"), "uncommitted")
+		var warning string
+		c.InfoFunc = func(msg string) { warning = msg }
+		if err := c.doUpdate(remoteUrl, "main"); err != nil {
+			t.Fatal(err)
+		}
+		if head(c, "HEAD") != before {
+			t.Error("expected a dirty diverged branch not to be reset")
+		}
+		if content, _ := os.ReadFile(filepath.Join(c.WorkingDir, "local")); string(content) != "uncommitted" {
+			t.Errorf("expected uncommitted changes to be kept, got '%s'", content)
+		}
+		if !strings.Contains(warning, "uncommitted changes") {
+			t.Errorf("expected a warning about uncommitted changes, got '%s'", warning)
+		}
+		if !c.DivergedSkipped {
+			t.Error("expected the repo to be reported as not updated")
+		}
+	})
+
+	t.Run(divergedPolicyRebase, func(t *testing.T) {
+		c, remoteUrl := newDivergedTestRepo(t, divergedForcePushed)
+		c.Diverged = divergedPolicyRebase
+		if err := c.doUpdate(remoteUrl, "main"); err != nil {
+			t.Fatal(err)
+		}
+		if head(c, "HEAD") != head(c, "origin/main") {
+			t.Error("expected commits dropped from the force-pushed upstream not to be replayed")
+		}
+		if c.DivergedSkipped {
+			t.Error("expected the repo to be reported as updated")
+		}
+	})
+}
+
+func TestSyncReportsDivergedRepo(t *testing.T) {
+	c, remoteUrl := newDivergedTestRepo(t, divergedLocalCommits)
+	workspace := t.TempDir()
+	t.Setenv("ORGIT_WORKSPACE", workspace)
+	r := RemoteRepo{
+		RepoName:      MustParseRepoName("github.com/org/repo"),
+		CloneUrl:      "https://github.com/org/repo.git",
+		DefaultBranch: "main",
+	}
+	localDir := r.RepoName.LocalPathAbsolute()
+	mkdirs(t, filepath.Dir(localDir))
+	if err := os.Rename(c.WorkingDir, localDir); err != nil {
+		t.Fatal(err)
+	}
+	gitInTest(t, localDir, "config", "--global", "url."+remoteUrl.Path+".insteadOf", r.CloneUrl)
+
+	history, err := openHistoryRecorder("run1", "github.com/org")
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := NewProgressLogger("quiet")
+	p := NewSyncReposWorkerPool(context.Background(), true, true, true, logger)
+	p.history = history
+	p.diverged = divergedPolicyWarn
+	err = p.doWork(r)
+	close(p.remoteReposChan)
+	_ = p.Wait()
+	history.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := readHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Action != historyActionDiverged {
+		t.Errorf("expected the repo to be recorded as diverged, got %+v", entries)
+	}
+	if stats := logger.statsStr(); stats != " (1 diverged)" {
+		t.Errorf("expected the summary to count the diverged repo, got '%s'", stats)
+	}
+}
+
+func TestBackupBranchName(t *testing.T) {
+	c, _ := newDivergedTestRepo(t, divergedLocalCommits)
+	now := time.Date(2026, 10, 18, 18, 47, 4, 0, time.Local)
+	for _, expected := range []string{"orgit/backup/2026-10-18", "orgit/backup/2026-10-18-2", "orgit/backup/2026-10-18-3"} {
+		name := c.backupBranchName(now)
+		if name != expected {
+			t.Errorf("expected %s, got %s", expected, name)
+		}
+		if _, err := c.doExec("git branch " + shellQuote(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/egymgmbh/go-prefix-writer/prefixer"
+	"github.com/fatih/color"
+	"github.com/mtibben/orgit/syncprinter"
+	ignore "github.com/sabhiram/go-gitignore"
+	"github.com/sourcegraph/conc/pool"
+	"github.com/spf13/cobra"
+)
+
+// repoSelector selects local repos to run commands in
+type repoSelector struct {
+	filters []string // gitignore-style patterns matched against the repo path
+	dirty   bool
+}
+
+// selectRepos returns the absolute paths of the git repos in baseDir that
+// match the selector, skipping repos matched by .orgitignore
+func (s repoSelector) selectRepos(baseDir string) []string {
+	orgitIgnore := getIgnorePatterns()
+	var filter *ignore.GitIgnore
+	if len(s.filters) > 0 {
+		filter = ignore.CompileIgnoreLines(s.filters...)
+	}
+
+	mu := sync.Mutex{}
+	dirs := []string{}
+	p := pool.New().WithMaxGoroutines(SyncWorkerPoolSize)
+	forEachGitDirIn(baseDir, func(relativeDir string) {
+		dir := filepath.Join(baseDir, relativeDir)
+		repoPath := filepath.ToSlash(relativeToWorkspace(dir))
+		if orgitIgnore.MatchesPath(repoPath) {
+			return
+		}
+		if filter != nil && !filter.MatchesPath(repoPath) {
+			return
+		}
+
+		p.Go(func() {
+			if s.dirty {
+				dirty, err := isDirty(dir)
+				if err != nil {
+					syncprinter.Println(err.Error())
+					return
+				}
+				if !dirty {
+					return
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			dirs = append(dirs, dir)
+		})
+	})
+	p.Wait()
+
+	slices.Sort(dirs)
+	return dirs
+}
+
+type execResult struct {
+	dir string
+	err error
+}
+
+func execInRepo(dir string, command []string, stdout, stderr io.Writer) execResult {
+	c := exec.Command(command[0], command[1:]...)
+	c.Dir = dir
+	c.Stdout = prefixer.New(stdout, func() string { return prefix(dir) })
+	c.Stderr = prefixer.New(stderr, func() string { return prefix(dir) })
+	return execResult{dir: dir, err: c.Run()}
+}
+
+// execInRepos runs command concurrently in each of dirs and returns the
+// results of the commands that failed, sorted by directory
+func execInRepos(dirs []string, command []string, jobs int, stdout, stderr io.Writer) []execResult {
+	mu := sync.Mutex{}
+	failed := []execResult{}
+	p := pool.New().WithMaxGoroutines(jobs)
+	for _, dir := range dirs {
+		p.Go(func() {
+			result := execInRepo(dir, command, stdout, stderr)
+			if result.err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				failed = append(failed, result)
+			}
+		})
+	}
+	p.Wait()
+
+	slices.SortFunc(failed, func(a, b execResult) int {
+		return strings.Compare(a.dir, b.dir)
+	})
+	return failed
+}
+
+// printExecSummary prints the repos that command failed in and returns the
+// exit code for orgit exec
+func printExecSummary(printer *syncprinter.Printer, failed []execResult, total int) int {
+	if len(failed) > 0 {
+		printer.Println(color.RedString("Failed in %d of %d repos:", len(failed), total))
+		for _, f := range failed {
+			printer.Println(fmt.Sprintf("  %s: %s", relativeToWorkspace(f.dir), f.err))
+		}
+		return 1
+	}
+	printer.Println(fmt.Sprintf("Succeeded in %d repos", total))
+	return 0
+}
+
+// addJobsFlag adds the --jobs flag to cmd. Values less than 1 are rejected
+// before the command runs, as the worker pool needs at least one goroutine.
+func addJobsFlag(cmd *cobra.Command, jobs *int, usage string) {
+	cmd.Flags().IntVarP(jobs, "jobs", "j", runtime.NumCPU(), usage)
+	cmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if *jobs < 1 {
+			return fmt.Errorf("--jobs must be at least 1, got %d", *jobs)
+		}
+		return nil
+	}
+}
+
+// completeExecArgs completes DIR from names, and anything after it as usual.
+// Cobra drops -- before completing, so the first word of a command given
+// without DIR is also completed as DIR.
+func completeExecArgs(names func() []string) completionFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) != 0 || strings.HasPrefix(toComplete, "-") {
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+		return completeRepoPath(toComplete, names(), false)
+	}
+}
+
+func init() {
+	selector := repoSelector{}
+	var jobs int
+
+	var cmdExec = &cobra.Command{
+		Use:   "exec [flags] [DIR] -- COMMAND [ARGS]...",
+		Short: "Run a command in each git repository",
+		Long: `Run a command concurrently in each git repository in DIR, or in the workspace path if DIR is not specified.
+
+Output is prefixed with the path of the repository. The command is not run
+through a shell, use "sh -c '...'" for pipes and redirection.
+
+Repos matching .orgitignore are skipped. Exits non-zero if the command fails
+in any repository.
+
+Examples:
+  orgit exec github.com/my-org -- go mod tidy
+  orgit exec --filter '*-service' --dirty -- git status --short
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			dash := cmd.ArgsLenAtDash()
+			if dash == -1 || dash == len(args) {
+				return errors.New("requires a COMMAND after --")
+			}
+			if dash > 1 {
+				return errors.New("accepts at most one DIR before --")
+			}
+			return nil
+		},
+		ValidArgsFunction: completeExecArgs(localRepoNames),
+		Run: func(cmd *cobra.Command, args []string) {
+			dash := cmd.ArgsLenAtDash()
+			command := args[dash:]
+
+			baseDir := getWorkspaceDir()
+			if dash == 1 {
+				baseDir = resolveWorkspacePath(baseDir, args[0])
+			}
+			if !dirExists(baseDir) {
+				cmd.PrintErrf("'%s' does not exist\n", baseDir)
+				os.Exit(1)
+			}
+
+			dirs := selector.selectRepos(baseDir)
+			failed := execInRepos(dirs, command, jobs, os.Stdout, os.Stderr)
+			if exitCode := printExecSummary(syncprinter.NewPrinter(os.Stderr), failed, len(dirs)); exitCode != 0 {
+				os.Exit(exitCode)
+			}
+		},
+	}
+
+	cmdExec.Flags().StringArrayVar(&selector.filters, "filter", nil, "Only run in repos whose path matches this gitignore-style pattern (can be repeated)")
+	cmdExec.Flags().BoolVar(&selector.dirty, "dirty", false, "Only run in repos with uncommitted changes")
+	addJobsFlag(cmdExec, &jobs, "Number of repos to run the command in concurrently")
+	rootCmd.AddCommand(cmdExec)
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/mtibben/orgit/syncprinter"
+	"github.com/spf13/cobra"
+)
+
+// newTestWorkspace creates a workspace containing a git repo for each of names
+func newTestWorkspace(t *testing.T, names ...string) string {
+	t.Helper()
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+	workspace := t.TempDir()
+	t.Setenv("ORGIT_WORKSPACE", workspace)
+	for _, name := range names {
+		mkdirs(t, filepath.Join(workspace, name))
+		gitInTest(t, filepath.Join(workspace, name), "init", "--quiet")
+	}
+	return workspace
+}
+
+func TestSelectRepos(t *testing.T) {
+	workspace := newTestWorkspace(t,
+		"github.com/org/api",
+		"github.com/org/web-service",
+		"github.com/org/ignored",
+		"github.com/other/db-service",
+	)
+	writeTestFile(t, filepath.Join(workspace, ".orgitignore"), "github.com/org/ignored\n")
+	writeTestFile(t, filepath.Join(workspace, "github.com/org/web-service/README"), "dirty")
+
+	tests := []struct {
+		name     string
+		baseDir  string
+		selector repoSelector
+		want     []string
+	}{
+		{"workspace", workspace, repoSelector{}, []string{"github.com/org/api", "github.com/org/web-service", "github.com/other/db-service"}},
+		{"org", filepath.Join(workspace, "github.com/org"), repoSelector{}, []string{"github.com/org/api", "github.com/org/web-service"}},
+		{"filter", workspace, repoSelector{filters: []string{"*-service"}}, []string{"github.com/org/web-service", "github.com/other/db-service"}},
+		{"dirty", workspace, repoSelector{dirty: true}, []string{"github.com/org/web-service"}},
+		{"filter in org", filepath.Join(workspace, "github.com/other"), repoSelector{filters: []string{"*-service"}}, []string{"github.com/other/db-service"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, dir := range tt.selector.selectRepos(tt.baseDir) {
+				got = append(got, relativeToWorkspace(dir))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestExecInRepos(t *testing.T) {
+	workspace := newTestWorkspace(t, "github.com/org/a", "github.com/org/b", "github.com/org/c")
+	writeTestFile(t, filepath.Join(workspace, "github.com/org/b/fail"), "")
+	dirs := []string{
+		filepath.Join(workspace, "github.com/org/a"),
+		filepath.Join(workspace, "github.com/org/b"),
+		filepath.Join(workspace, "github.com/org/c"),
+	}
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	failed := execInRepos(dirs, []string{"sh", "-c", "echo out; if [ -f fail ]; then echo oops >&2; exit 3; fi"}, 1, stdout, stderr)
+
+	expectedStdout := "github.com/org/a out\ngithub.com/org/b out\ngithub.com/org/c out\n"
+	if stdout.String() != expectedStdout {
+		t.Errorf("Expected stdout %q, got %q", expectedStdout, stdout.String())
+	}
+	if stderr.String() != "github.com/org/b oops\n" {
+		t.Errorf("Expected prefixed stderr, got %q", stderr.String())
+	}
+	if len(failed) != 1 || failed[0].dir != dirs[1] {
+		t.Fatalf("Expected only github.com/org/b to fail, got %v", failed)
+	}
+
+	summary := &bytes.Buffer{}
+	exitCode := printExecSummary(syncprinter.NewPrinter(summary), failed, len(dirs))
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1, got %d", exitCode)
+	}
+	expectedSummary := "Failed in 1 of 3 repos:\n  github.com/org/b: exit status 3\n"
+	if summary.String() != expectedSummary {
+		t.Errorf("Expected summary %q, got %q", expectedSummary, summary.String())
+	}
+
+	failed = execInRepos(dirs, []string{"true"}, 2, &bytes.Buffer{}, &bytes.Buffer{})
+	summary.Reset()
+	exitCode = printExecSummary(syncprinter.NewPrinter(summary), failed, len(dirs))
+	if exitCode != 0 || summary.String() != "Succeeded in 3 repos\n" {
+		t.Errorf("Expected success, got exit code %d and %q", exitCode, summary.String())
+	}
+}
+
+func TestAddJobsFlag(t *testing.T) {
+	for _, arg := range []string{"0", "-1"} {
+		var jobs int
+		cmd := &cobra.Command{}
+		addJobsFlag(cmd, &jobs, "")
+		if err := cmd.ParseFlags([]string{"--jobs=" + arg}); err != nil {
+			t.Fatal(err)
+		}
+		err := cmd.PreRunE(cmd, nil)
+		if err == nil || !strings.Contains(err.Error(), "--jobs must be at least 1") {
+			t.Errorf("Expected --jobs=%s to be rejected, got %v", arg, err)
+		}
+	}
+
+	var jobs int
+	cmd := &cobra.Command{}
+	addJobsFlag(cmd, &jobs, "")
+	if err := cmd.ParseFlags([]string{"-j", "3"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.PreRunE(cmd, nil); err != nil || jobs != 3 {
+		t.Errorf("Expected -j 3 to be accepted, got %d, %v", jobs, err)
+	}
+}
+
+func TestCompleteExecArgs(t *testing.T) {
+	complete := completeExecArgs(func() []string { return []string{"github.com/org/api"} })
+	tests := []struct {
+		args       []string
+		toComplete string
+		want       []string
+		directive  cobra.ShellCompDirective
+	}{
+		{nil, "github.com/org/", []string{"github.com/org/api"}, cobra.ShellCompDirectiveNoFileComp},
+		{nil, "-", nil, cobra.ShellCompDirectiveDefault},
+		{[]string{"github.com/org/api"}, "git", nil, cobra.ShellCompDirectiveDefault},
+		{[]string{"github.com/org/api", "git"}, "st", nil, cobra.ShellCompDirectiveDefault},
+	}
+	for _, tt := range tests {
+		got, directive := complete(&cobra.Command{}, tt.args, tt.toComplete)
+		if !slices.Equal(got, tt.want) || directive != tt.directive {
+			t.Errorf("%v %q: expected %v and %d, got %v and %d", tt.args, tt.toComplete, tt.want, tt.directive, got, directive)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"math"
+	"slices"
+	"strings"
+)
+
+const (
+	fuzzyMatch       = 16 // for each matched character
+	fuzzyBoundary    = 8  // at the start of a path element or word
+	fuzzyLastElement = 4  // in the last path element, usually the repo's name
+	fuzzyConsecutive = 8  // following the previous matched character
+	fuzzyGap         = 3  // skipping characters between matches
+)
+
+func isFuzzyBoundary(r rune) bool {
+	return strings.ContainsRune("/-_. ", r)
+}
+
+// fuzzyScore scores how well pattern matches s as a case-insensitive
+// subsequence, e.g. "orgapi" matches github.com/my-org/api. Matches at the
+// start of path elements and words, in the last path element and of
+// consecutive characters score higher. It returns false if pattern doesn't
+// match.
+func fuzzyScore(pattern, s string) (int, bool) {
+	p := []rune(strings.ToLower(pattern))
+	r := []rune(strings.ToLower(s))
+	if len(p) == 0 {
+		return 0, true
+	}
+	if len(p) > len(r) {
+		return 0, false
+	}
+
+	lastElement := 0
+	for j, c := range r {
+		if c == '/' {
+			lastElement = j + 1
+		}
+	}
+	bonus := func(j int) int {
+		b := fuzzyMatch
+		if j == 0 || isFuzzyBoundary(r[j-1]) {
+			b += fuzzyBoundary
+		}
+		if j >= lastElement {
+			b += fuzzyLastElement
+		}
+		return b
+	}
+
+	// prev[j] is the best score of the pattern so far with its last character
+	// matched at r[j]
+	const none = math.MinInt / 2
+	prev := make([]int, len(r))
+	cur := make([]int, len(r))
+	for i := range p {
+		bestBefore := none // the best of prev[k] for k < j-1
+		for j := range r {
+			if i > 0 && j >= 2 {
+				bestBefore = max(bestBefore, prev[j-2])
+			}
+			cur[j] = none
+			if r[j] != p[i] {
+				continue
+			}
+			if i == 0 {
+				cur[j] = bonus(j)
+				continue
+			}
+			if j >= 1 && prev[j-1] > none {
+				cur[j] = prev[j-1] + bonus(j) + fuzzyConsecutive
+			}
+			if bestBefore > none {
+				cur[j] = max(cur[j], bestBefore+bonus(j)-fuzzyGap)
+			}
+		}
+		prev, cur = cur, prev
+	}
+
+	best := slices.Max(prev)
+	if best <= none {
+		return 0, false
+	}
+	return best, true
+}
+
+// fuzzyRank returns the indexes of the candidates matching pattern, best
+// first. Candidates with the same score are shortest first, then in their
+// original order.
+func fuzzyRank(pattern string, candidates []string) []int {
+	type scored struct {
+		i     int
+		score int
+	}
+	matches := []scored{}
+	for i, c := range candidates {
+		if score, ok := fuzzyScore(pattern, c); ok {
+			matches = append(matches, scored{i, score})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b scored) int {
+		if a.score != b.score {
+			return b.score - a.score
+		}
+		return len(candidates[a.i]) - len(candidates[b.i])
+	})
+
+	result := make([]int, len(matches))
+	for i, m := range matches {
+		result[i] = m.i
+	}
+	return result
+}
+
+// fuzzyFilter returns the candidates matching pattern in the order of
+// fuzzyRank
+func fuzzyFilter(pattern string, candidates []string) []string {
+	result := []string{}
+	for _, i := range fuzzyRank(pattern, candidates) {
+		result = append(result, candidates[i])
+	}
+	return result
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		pattern, s string
+		match      bool
+	}{
+		{"", "github.com/org/api", true},
+		{"api", "github.com/org/api", true},
+		{"API", "github.com/org/api", true},
+		{"orgapi", "github.com/org/api", true},
+		{"gh/api", "github.com/org/api", true},
+		{"ipa", "github.com/org/api", false},
+		{"api-service-long", "api", false},
+	}
+	for _, tt := range tests {
+		_, ok := fuzzyScore(tt.pattern, tt.s)
+		if ok != tt.match {
+			t.Errorf("fuzzyScore(%q, %q) matched %v, want %v", tt.pattern, tt.s, ok, tt.match)
+		}
+	}
+
+	better := func(pattern, a, b string) {
+		t.Helper()
+		sa, _ := fuzzyScore(pattern, a)
+		sb, _ := fuzzyScore(pattern, b)
+		if sa <= sb {
+			t.Errorf("expected '%s' (%d) to score higher than '%s' (%d) for '%s'", a, sa, b, sb, pattern)
+		}
+	}
+	better("api", "github.com/org/api", "github.com/apple/pie")
+	better("api", "github.com/org/my-api", "github.com/org/mapping")
+	better("web", "github.com/web-org/tools", "github.com/org/wxexb")
+}
+
+func TestFuzzyFilter(t *testing.T) {
+	candidates := []string{
+		"github.com/org/api-gateway",
+		"github.com/org/web",
+		"github.com/org/api",
+		"gitlab.com/group/apple-pie",
+	}
+	got := fuzzyFilter("api", candidates)
+	want := []string{"github.com/org/api", "github.com/org/api-gateway", "gitlab.com/group/apple-pie"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -16,47 +16,8 @@ import (
 
 var osUserHomeDirFunc = os.UserHomeDir
 
-func mustParseGitRepo(s string) *url.URL {
-	u, err := url.Parse(s)
-	if err != nil {
-		panic(err)
-	}
-	return u
-}
-
-var sshLocationFormat = regexp.MustCompile(`^([^@]+@[^:]+):(.+)$`)
-
-func getGitUrl(origGitUrlStr string) (*url.URL, error) {
-	gitUrlStr := origGitUrlStr
-
-	// normalise git URL for known git providers
-	for _, provider := range KnownGitProviders {
-		if provider.IsMatch(origGitUrlStr) {
-			gitUrlStr = provider.NormaliseGitUrl(origGitUrlStr)
-			break
-		}
-	}
-
-	gitUrl, err := url.Parse(gitUrlStr)
-	if err != nil {
-		if sshLocationFormat.MatchString(gitUrlStr) {
-			parts := sshLocationFormat.FindStringSubmatch(gitUrlStr)
-			gitUrl = mustParseGitRepo(fmt.Sprintf("ssh://%s/%s", parts[2], parts[3]))
-		} else {
-			return nil, fmt.Errorf("invalid git url '%s", origGitUrlStr)
-		}
-	}
-
-	return gitUrl, nil
-}
-
 func parseArgsForGetCmd(projectUrl string) (gitUrl *url.URL, commitOrBranch string, err error) {
-	arg0parts := strings.Split(projectUrl, "@")
-	projectUrlStr := arg0parts[0]
-
-	if len(arg0parts) > 1 {
-		commitOrBranch = arg0parts[1]
-	}
+	projectUrlStr, commitOrBranch := splitRefFromGitUrl(projectUrl)
 
 	gitUrl, err = getGitUrl(projectUrlStr)
 	if err != nil {
@@ -71,19 +32,29 @@ func getTrashDir() string {
 }
 
 func getLocalDir(gitUrl *url.URL) string {
-	localDir := filepath.Join(getWorkspaceDir(), gitUrl.Host, gitUrl.Path)
-	localDir = strings.TrimSuffix(localDir, ".git")
-
-	return localDir
+	return repoNameFromGitUrl(gitUrl).LocalPathAbsolute()
 }
 
 func newShellCmd(shCmd string) *exec.Cmd {
 	return exec.Command("sh", "-c", shCmd)
 }
 
-func (c *getCmdContext) doExec(shCmd string) (string, error) {
+// shellQuote quotes s for use as a single argument in a shell command
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func (c *getCmdContext) newShellCmd(shCmd string) *exec.Cmd {
 	cmd := newShellCmd(shCmd)
 	cmd.Dir = c.WorkingDir
+	if len(c.Env) > 0 {
+		cmd.Env = append(os.Environ(), c.Env...)
+	}
+	return cmd
+}
+
+func (c *getCmdContext) doExec(shCmd string) (string, error) {
+	cmd := c.newShellCmd(shCmd)
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		err = fmt.Errorf("error executing '%s' in directory '%s': %w", shCmd, c.WorkingDir, err)
@@ -99,8 +70,10 @@ func dirExists(dir string) bool {
 	return info.IsDir()
 }
 
+// isGitRepo returns true for a repo or a worktree of a repo, which has a
+// .git file rather than a directory
 func isGitRepo(dir string) bool {
-	return dirExists(filepath.Join(dir, ".git"))
+	return dirExists(filepath.Join(dir, ".git")) || isWorktree(dir)
 }
 
 func (c *getCmdContext) echoEvalf(shCmd string, a ...any) error {
@@ -109,8 +82,7 @@ func (c *getCmdContext) echoEvalf(shCmd string, a ...any) error {
 
 func (c *getCmdContext) echoEval(shCmd string) error {
 	c.CmdEchoFunc(shCmd, c.WorkingDir)
-	cmd := newShellCmd(shCmd)
-	cmd.Dir = c.WorkingDir
+	cmd := c.newShellCmd(shCmd)
 	cmd.Stdout = c.Stdout
 	cmd.Stderr = c.Stderr
 	err := cmd.Run()
@@ -122,45 +94,149 @@ func (c *getCmdContext) echoEval(shCmd string) error {
 
 func init() {
 	var update bool
+	var wait bool
+	var restoreStash bool
+	var strategy string
+	var diverged string
+	var skipLfs bool
+	var worktree bool
+	var logLevel string
 
 	var cmdGet = &cobra.Command{
 		Use:   "get [flags] PROJECT_URL[@COMMIT]...",
 		Short: "Clone or checkout a git repository into the workspace directory",
 		Long: `Clone or checkout a git repository into the workspace directory.
 
-If the worktree has been modified, the changes are stashed.
+If the worktree has been modified, the changes are stashed. Use --restore-stash
+to re-apply them after fast-forwarding if they apply cleanly, and see
+'orgit stashes' for the stashes left behind. See 'orgit sync --help' for the
+other update strategies.
+
+A pull request or merge request given as pr/NUMBER or mr/NUMBER is fetched
+into a local branch of the same name that tracks it, so --update re-fetches it.
+Use --worktree to check it out in its own worktree under
+$ORGIT_WORKSPACE/.worktrees rather than switching the repo's branch.
+
+For GitHub and GitLab repos, the repo is looked up with the API so that a
+renamed or transferred repo is got at its current path, updates use the default
+branch reported by the API, and archived repos are warned about. Repos on other
+hosts, or that can't be looked up within 10s, are got as given. A repo that's
+already cloned isn't looked up without --update.
+
+More than one repo is got concurrently, continuing past failures, which are
+summarised at the end. A PROJECT_URL with glob characters is matched against the
+unarchived repos of the org, user or group listed with the GitHub or GitLab API,
+leaving out repos in .orgitignore, e.g.
+
+  orgit get 'github.com/my-org/*-service'
+
+A PROJECT_URL of - reads the repos to get from stdin, one per line, e.g. from
+'orgit search --names'.
 
 Arguments:
-  PROJECT_URL  URL of the gitlab or github project, or a relative path to a project in the default directory
-  COMMIT       The ref name or hash to checkout. Defaults to the remote HEAD.
+  PROJECT_URL  URL of the gitlab or github project, a relative path to a project in the default directory, or a glob pattern
+  COMMIT       The ref name or hash to checkout, or pr/NUMBER or mr/NUMBER. Defaults to the remote HEAD.
 `,
-		Args: cobra.MinimumNArgs(1),
+		Args:              cobra.MinimumNArgs(1),
+		ValidArgsFunction: completeRepoArgs(knownRepoNames, false),
 		Run: func(cmd *cobra.Command, args []string) {
-			for _, gitUrlArg := range args {
-				gitUrl, branchOrCommit, err := parseArgsForGetCmd(gitUrlArg)
+			if update {
+				lock, err := acquireWorkspaceLock(wait, func(msg string) { cmd.PrintErrln(msg) })
 				if err != nil {
 					cmd.PrintErrln(err)
 					os.Exit(1)
 				}
+				defer lock.Release()
+			}
 
-				dir := getLocalDir(gitUrl)
+			transports, err := newTransportSelector()
+			if err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+			opts := getOptions{
+				update:       update,
+				restoreStash: restoreStash,
+				worktree:     worktree,
+				skipLfs:      skipLfs,
+				diverged:     diverged,
+				transports:   transports,
+			}
+			opts.strategies, err = newUpdateStrategySelector(strategy)
+			if err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+			err = validateDivergedPolicy(diverged)
+			if err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+
+			if len(args) == 1 && args[0] == "-" {
+				args, err = readArgsFromStdin(os.Stdin)
+				if err != nil {
+					cmd.PrintErrln(err)
+					os.Exit(1)
+				}
+				if len(args) == 0 {
+					cmd.PrintErrln("no repos given on stdin")
+					os.Exit(1)
+				}
+			}
+
+			requests, err := resolveGetArgs(cmd.Context(), args, transports)
+			if err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+			for _, req := range requests {
+				err = opts.checkGetRequest(req)
+				if err != nil {
+					cmd.PrintErrln(err)
+					os.Exit(1)
+				}
+			}
 
-				getCmdContext := &getCmdContext{
-					Stdout:      os.Stdout,
-					Stderr:      os.Stderr,
-					CmdEchoFunc: func(cmd, dir string) { color.Cyan(" + %s", cmd) },
-					WorkingDir:  dir,
+			// a single repo shows git's output as it happens
+			if len(requests) == 1 && !isGlobPattern(args[0]) {
+				req, err := opts.resolve(cmd.Context(), requests[0])
+				if err != nil {
+					cmd.PrintErrln(err)
+					os.Exit(1)
 				}
-				err = getCmdContext.doGet(gitUrl, branchOrCommit, update)
+				c := opts.newGetCmdContext(req, os.Stdout, os.Stderr, func(cmd, dir string) { color.Cyan(" + %s", cmd) })
+				err = opts.get(cmd.Context(), c, req)
 				if err != nil {
 					cmd.PrintErrln(err)
 					os.Exit(1)
 				}
+				return
+			}
+
+			logger := NewProgressLogger(logLevel)
+			logger.Activity = "Getting"
+			failures := doGetAll(cmd.Context(), requests, opts, logger)
+			if len(failures) > 0 {
+				logger.EndProgressLine("didn't fully complete")
+				cmd.PrintErrf("Couldn't get %d of %d repos:\n", len(failures), len(requests))
+				for _, f := range failures {
+					cmd.PrintErrf("  %s: %s\n", relativeToWorkspace(f.Dir), f.Err)
+				}
+				os.Exit(1)
 			}
+			logger.EndProgressLine("done")
 		},
 	}
 
 	cmdGet.Flags().BoolVar(&update, "update", false, "Stash uncommitted changes and pull the latest changes from the remote")
+	cmdGet.Flags().StringVar(&strategy, "strategy", "", "With --update, how to update the repo: default, fetch-only, ff-current or rebase. Overrides the target's strategy in $ORGIT_WORKSPACE/.orgitconfig")
+	cmdGet.Flags().StringVar(&diverged, "diverged", divergedPolicyWarn, "With --update, what to do when the branch has diverged from its upstream: warn, backup-reset or rebase")
+	cmdGet.Flags().StringVar(&logLevel, "log-level", "info", "When getting more than one repo, set the log level (debug, verbose, info, quiet)")
+	cmdGet.Flags().BoolVar(&worktree, "worktree", false, "Check out a pull or merge request in its own worktree")
+	cmdGet.Flags().BoolVar(&skipLfs, "skip-lfs", false, "Don't download LFS objects, leaving pointer files")
+	cmdGet.Flags().BoolVar(&restoreStash, "restore-stash", false, "With --update, re-apply stashed changes after fast-forwarding if they apply cleanly")
+	cmdGet.Flags().BoolVar(&wait, "wait", false, "With --update, wait for another orgit process to release the workspace lock instead of failing")
 
 	rootCmd.AddCommand(cmdGet)
 }
@@ -170,6 +246,44 @@ type getCmdContext struct {
 	Stdout      io.Writer
 	Stderr      io.Writer
 	CmdEchoFunc func(cmd, dir string)
+	Progress    bool // force git to report progress even when not writing to a terminal
+
+	Strategy      string   // how to update an existing repo, defaults to updateStrategyDefault
+	RestoreStash  bool     // re-apply the orgit stash after a successful fast-forward
+	Prune         bool     // remove remote-tracking branches deleted on the remote when fetching
+	Diverged      string   // policy for branches that can't be fast-forwarded, defaults to divergedPolicyWarn
+	Env           []string // extra environment variables for git commands
+	Transport     bool     // gitUrl uses the configured transport, which the origin remote is changed to
+	DefaultBranch string   // from the provider's API, otherwise found from origin/HEAD
+	LfsPull       bool     // download LFS objects after cloning or updating
+
+	// set after cloning or updating
+	SubmodulesUpdated bool
+	LfsPulled         bool
+	DivergedSkipped   bool             // a diverged branch was left alone, so the repo wasn't updated
+	InfoFunc          func(msg string) // reports messages that aren't errors, defaults to writing to Stderr
+}
+
+func (c *getCmdContext) info(msg string) {
+	if c.InfoFunc != nil {
+		c.InfoFunc(msg)
+		return
+	}
+	fmt.Fprintln(c.Stderr, msg)
+}
+
+func (c *getCmdContext) pruneFlag() string {
+	if c.Prune {
+		return " --prune"
+	}
+	return ""
+}
+
+func (c *getCmdContext) progressFlag() string {
+	if c.Progress {
+		return " --progress"
+	}
+	return ""
 }
 
 func (c *getCmdContext) doGet(gitUrl *url.URL, branchOrCommit string, update bool) error {
@@ -178,7 +292,7 @@ func (c *getCmdContext) doGet(gitUrl *url.URL, branchOrCommit string, update boo
 			return fmt.Errorf("'%s' already exists but is not a git repository", c.WorkingDir)
 		}
 		if update {
-			fmt.Fprintf(os.Stderr, "In '%s'\n", c.WorkingDir)
+			fmt.Fprintf(c.Stderr, "In '%s'\n", c.WorkingDir)
 			return c.doUpdate(gitUrl, branchOrCommit)
 		}
 	} else {
@@ -211,6 +325,10 @@ func (c *getCmdContext) hasNoCommits() bool {
 }
 
 func (c *getCmdContext) getDefaultBranchName() (string, error) {
+	if c.DefaultBranch != "" {
+		return c.DefaultBranch, nil
+	}
+
 	defaultBranch, err1 := c.doExec(`git symbolic-ref --short refs/remotes/origin/HEAD`)
 	if err1 != nil {
 
@@ -251,8 +369,21 @@ func (c *getCmdContext) fixRemoteConfig(gitUrl *url.URL) error {
 	if err != nil {
 		return fmt.Errorf("error getting remote origin url: %w", err)
 	}
-	if remoteOriginUrl != gitUrl.String() {
-		err := c.echoEvalf(`git remote set-url origin %s`, gitUrl.String())
+	// without a configured transport, keep the transport the user chose for
+	// the origin remote, e.g. ssh rather than https, and only change the URL
+	// when the repo has moved
+	newRemoteUrl := gitUrl.String()
+	if existing, err := getRemoteGitUrl(remoteOriginUrl); err == nil {
+		sameRepo := repoNameFromGitUrl(existing) == repoNameFromGitUrl(gitUrl)
+		if sameRepo && (!c.Transport || sameTransport(existing, gitUrl)) {
+			return nil
+		}
+		if !c.Transport {
+			newRemoteUrl = remoteUrlWithTransportOf(remoteOriginUrl, repoNameFromGitUrl(gitUrl))
+		}
+	}
+	if remoteOriginUrl != newRemoteUrl {
+		err := c.echoEvalf(`git remote set-url origin %s`, newRemoteUrl)
 		if err != nil {
 			return fmt.Errorf("error setting remote origin url: %w", err)
 		}
@@ -261,19 +392,48 @@ func (c *getCmdContext) fixRemoteConfig(gitUrl *url.URL) error {
 	return nil
 }
 
-func (c *getCmdContext) isLocked() bool {
-	return fileExists(filepath.Join(c.WorkingDir, ".git", "index.lock"))
+// lockedError returns an error if another git process seems to be running in
+// the repo, as its index.lock exists
+func (c *getCmdContext) lockedError() error {
+	indexLock := gitPath(c.WorkingDir, "index.lock")
+	if fileExists(indexLock) {
+		return fmt.Errorf("can't update '%s', another git process seems to be running in this repository: %s exists", c.WorkingDir, indexLock)
+	}
+	return nil
 }
 
-func (c *getCmdContext) stash() error {
-	err := c.echoEval(`git stash push --include-untracked --message "orgit"`)
+func (c *getCmdContext) stashRef() string {
+	out, _ := c.doExec(`git rev-parse --quiet --verify refs/stash`)
+	return out
+}
+
+// stash stashes any uncommitted changes and returns true if a stash was created
+func (c *getCmdContext) stash() (bool, error) {
+	before := c.stashRef()
+	err := c.echoEvalf(`git stash push --include-untracked --message %s`, orgitStashMessage)
 	if err != nil {
 		if c.hasNoCommits() {
-			return errNoCommits
+			return false, errNoCommits
 		}
-		return fmt.Errorf("error stashing uncommitted changes: %w", err)
+		return false, fmt.Errorf("error stashing uncommitted changes: %w", err)
+	}
+
+	return c.stashRef() != before, nil
+}
+
+// restoreStash pops the stash created by stash if it applies cleanly,
+// otherwise the stash is left in place
+func (c *getCmdContext) restoreStash() error {
+	_, err := c.doExec(`git stash show --include-untracked --binary --patch stash@{0} | git apply --check`)
+	if err != nil {
+		c.info(fmt.Sprintf("Left stash in '%s' in place, it doesn't apply cleanly", c.WorkingDir))
+		return nil
 	}
 
+	err = c.echoEval(`git stash pop`)
+	if err != nil {
+		return fmt.Errorf("error restoring stash: %w", err)
+	}
 	return nil
 }
 
@@ -282,8 +442,37 @@ func (c *getCmdContext) isABranch(branchOrCommit string) bool {
 }
 
 func (c *getCmdContext) doUpdate(gitUrl *url.URL, branchOrCommit string) error {
-	if c.isLocked() {
-		return fmt.Errorf("can't update '%s', another git process seems to be running in this repository: .git/index.lock exists", c.WorkingDir)
+	err := c.updateRepo(gitUrl, branchOrCommit)
+	if err != nil || c.Strategy == updateStrategyFetchOnly {
+		return err
+	}
+	err = c.fastForwardWorktrees()
+	if err != nil {
+		return err
+	}
+	return c.updateCheckoutExtras()
+}
+
+// updateCheckoutExtras brings submodules and LFS objects in line with the
+// current checkout
+func (c *getCmdContext) updateCheckoutExtras() error {
+	var err error
+	c.SubmodulesUpdated, err = c.updateSubmodules()
+	if err != nil {
+		return err
+	}
+	if c.LfsPull {
+		c.LfsPulled, err = c.pullLfs()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *getCmdContext) updateRepo(gitUrl *url.URL, branchOrCommit string) error {
+	if err := c.lockedError(); err != nil {
+		return err
 	}
 
 	err := c.fixRemoteConfig(gitUrl)
@@ -291,7 +480,7 @@ func (c *getCmdContext) doUpdate(gitUrl *url.URL, branchOrCommit string) error {
 		return fmt.Errorf("error fixing remote config: %w", err)
 	}
 
-	err = c.echoEvalf(`git fetch origin`)
+	err = c.echoEvalf(`git fetch%s%s origin`, c.progressFlag(), c.pruneFlag())
 	if err != nil {
 		return fmt.Errorf("error fetching origin: %w", err)
 	}
@@ -300,6 +489,13 @@ func (c *getCmdContext) doUpdate(gitUrl *url.URL, branchOrCommit string) error {
 		return nil // nothing we can do on a repo without commits
 	}
 
+	switch c.Strategy {
+	case updateStrategyFetchOnly:
+		return nil
+	case updateStrategyFFCurrent, updateStrategyRebase:
+		return c.updateCurrentBranch()
+	}
+
 	if branchOrCommit == "" {
 		branchOrCommit, err = c.getDefaultBranchName()
 		if err != nil {
@@ -316,8 +512,19 @@ func (c *getCmdContext) doUpdate(gitUrl *url.URL, branchOrCommit string) error {
 		return fmt.Errorf("can't update '%s', HEAD is detached", c.WorkingDir)
 	}
 
+	stashedFromBranch, err := c.doExec(`git rev-parse --abbrev-ref HEAD`)
+	if err != nil {
+		return fmt.Errorf("error getting current branch: %w", err)
+	}
+
+	// a branch can only be checked out in one worktree, where it's fast-forwarded instead
+	if w, found, _ := worktreeWithBranch(c.WorkingDir, branchOrCommit); found && !w.Main {
+		c.info(fmt.Sprintf("Not checking out '%s' in '%s', it's checked out in worktree '%s'", branchOrCommit, c.WorkingDir, w.Path))
+		return nil
+	}
+
 	// optimistically stash any uncommitted changes
-	err = c.stash()
+	stashed, err := c.stash()
 	if err != nil {
 		return fmt.Errorf("error stashing: %w", err)
 	}
@@ -328,25 +535,74 @@ func (c *getCmdContext) doUpdate(gitUrl *url.URL, branchOrCommit string) error {
 	}
 
 	if c.isABranch(branchOrCommit) {
-		err = c.echoEvalf(`git merge --ff-only @{u}`)
+		err = c.mergeUpstream(branchOrCommit)
 		if err != nil {
-			return fmt.Errorf("error fast-forwarding branch '%s': %w", branchOrCommit, err)
+			return err
+		}
+
+		// only restore changes onto the branch they were made on
+		if stashed && c.RestoreStash && branchOrCommit == stashedFromBranch {
+			return c.restoreStash()
 		}
 	}
 	return nil
 }
 
+// updateCurrentBranch brings the checked out branch up to date with its
+// upstream, without stashing or switching branches. With the rebase strategy,
+// unpushed commits are rebased onto the upstream rather than refusing to update.
+func (c *getCmdContext) updateCurrentBranch() error {
+	isDetachedHead, err := c.isDetachedHead()
+	if err != nil {
+		return fmt.Errorf("error checking if HEAD is detached: %w", err)
+	}
+	if isDetachedHead {
+		return fmt.Errorf("can't update '%s', HEAD is detached", c.WorkingDir)
+	}
+
+	upstream, err := c.doExec(`git rev-parse --abbrev-ref --symbolic-full-name @{u}`)
+	if err != nil {
+		c.info(fmt.Sprintf("Not updating '%s', the current branch has no upstream", c.WorkingDir))
+		return nil
+	}
+
+	if c.Strategy == updateStrategyRebase {
+		ahead, err := c.doExec(`git rev-list --count @{u}..HEAD`)
+		if err != nil {
+			return fmt.Errorf("error counting unpushed commits: %w", err)
+		}
+		if ahead != "0" {
+			err = c.echoEval(`git rebase --autostash @{u}`)
+			if err != nil {
+				_, _ = c.doExec(`git rebase --abort`)
+				return fmt.Errorf("error rebasing onto '%s', the rebase was aborted: %w", upstream, err)
+			}
+			return nil
+		}
+	}
+
+	branch, err := c.doExec(`git symbolic-ref --short HEAD`)
+	if err != nil {
+		return fmt.Errorf("error getting current branch: %w", err)
+	}
+	return c.mergeUpstream(branch)
+}
+
 func (c *getCmdContext) doClone(gitUrl, branchOrCommit string) error {
 	destinationDir := c.WorkingDir
 	c.WorkingDir = ""
 
-	err := c.echoEvalf(`git clone --recursive %s %s`, gitUrl, destinationDir)
+	err := c.echoEvalf(`git clone%s --recursive %s %s`, c.progressFlag(), gitUrl, destinationDir)
+	c.WorkingDir = destinationDir
 	if err != nil {
 		return fmt.Errorf("error cloning '%s' into '%s': %w", gitUrl, destinationDir, err)
 	}
 	if branchOrCommit != "" {
-		return c.echoEvalf(`git checkout %s`, branchOrCommit)
+		err = c.echoEvalf(`git checkout %s`, branchOrCommit)
+		if err != nil {
+			return err
+		}
 	}
 
-	return nil
+	return c.updateCheckoutExtras()
 }
@@ -0,0 +1,343 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sourcegraph/conc/pool"
+)
+
+// getRequest is a repo to get, from an argument to 'orgit get'
+type getRequest struct {
+	Arg       string
+	GitUrl    *url.URL
+	Ref       string
+	Transport bool // GitUrl uses the configured transport
+	Naked     bool // given without a transport, so the configured transport applies
+
+	// from the provider's API when the host has a known provider
+	DefaultBranch string
+	Archived      bool
+	RenamedFrom   RepoName // the name given, if the repo has been renamed or transferred
+	Listed        bool     // found by listing repos with the provider's API, so already canonical
+}
+
+// getOptions are the options of 'orgit get' that apply to every repo
+type getOptions struct {
+	update       bool
+	restoreStash bool
+	worktree     bool
+	skipLfs      bool
+	diverged     string
+	strategies   updateStrategySelector
+	transports   transportSelector
+}
+
+// providerLookupTimeout bounds looking up a repo or review with the
+// provider's API, so that a slow API can't hold up getting
+const providerLookupTimeout = 10 * time.Second
+
+// isGlobPattern returns true if the path of a repo argument has glob
+// characters, e.g. github.com/my-org/*-service
+func isGlobPattern(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+// splitGlobPattern splits a glob pattern into the org, user or group to list
+// repos from, which is the path before the first element with glob characters
+func splitGlobPattern(pattern string) (RepoName, error) {
+	elems := strings.Split(strings.Trim(pattern, "/"), "/")
+	i := slices.IndexFunc(elems, isGlobPattern)
+	if i < 2 {
+		return RepoName{}, fmt.Errorf("invalid pattern '%s', the host and org can't contain glob characters", pattern)
+	}
+	return RepoName{Host: elems[0], Path: strings.Join(elems[1:i], "/")}, nil
+}
+
+// matchRepoGlob returns the repos whose name matches pattern, sorted by name
+func matchRepoGlob(pattern string, repos []RemoteRepo) ([]RemoteRepo, error) {
+	pattern = strings.TrimSuffix(strings.Trim(pattern, "/"), ".git")
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern '%s': %w", pattern, err)
+	}
+
+	matched := []RemoteRepo{}
+	for _, r := range repos {
+		if ok, _ := path.Match(pattern, r.RepoName.String()); ok {
+			matched = append(matched, r)
+		}
+	}
+	slices.SortFunc(matched, func(a, b RemoteRepo) int {
+		return strings.Compare(a.RepoName.String(), b.RepoName.String())
+	})
+	return matched, nil
+}
+
+// listReposMatching lists the unarchived repos matching pattern with the
+// provider's API, leaving out repos in .orgitignore
+func listReposMatching(ctx context.Context, pattern string) ([]RemoteRepo, error) {
+	org, err := splitGlobPattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := RepoProviderFor(org.String() + "/")
+	if err != nil {
+		return nil, fmt.Errorf("couldn't find provider for '%s': %w", pattern, err)
+	}
+
+	repos := []RemoteRepo{}
+	ch := make(chan RemoteRepo)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for r := range ch {
+			repos = append(repos, r)
+		}
+	}()
+	err = provider.ListRepos(ctx, org.Path, false, ch)
+	close(ch)
+	<-done
+	if err != nil {
+		return nil, fmt.Errorf("couldn't list repos for '%s': %w", org, err)
+	}
+
+	ignored := getIgnorePatterns()
+	repos = slices.DeleteFunc(repos, func(r RemoteRepo) bool {
+		return ignored.MatchesPath(r.RepoName.String())
+	})
+	return matchRepoGlob(pattern, repos)
+}
+
+// resolveGetArgs parses the arguments of 'orgit get' into the repos to get,
+// expanding glob patterns into the matching repos
+func resolveGetArgs(ctx context.Context, args []string, transports transportSelector) ([]getRequest, error) {
+	requests := []getRequest{}
+	for _, arg := range args {
+		projectUrl, ref := splitRefFromGitUrl(arg)
+
+		if isGlobPattern(projectUrl) {
+			repos, err := listReposMatching(ctx, projectUrl)
+			if err != nil {
+				return nil, err
+			}
+			if len(repos) == 0 {
+				return nil, fmt.Errorf("no repos match '%s'", projectUrl)
+			}
+			for _, r := range repos {
+				gitUrl, err := getGitUrl(r.CloneUrl)
+				if err != nil {
+					return nil, err
+				}
+				req := getRequest{Arg: arg, Ref: ref, DefaultBranch: r.DefaultBranch, Listed: true}
+				req.GitUrl, req.Transport = transports.Apply(gitUrl)
+				requests = append(requests, req)
+			}
+			continue
+		}
+
+		gitUrl, err := getGitUrl(projectUrl)
+		if err != nil {
+			return nil, err
+		}
+		req := getRequest{Arg: arg, GitUrl: gitUrl, Ref: ref, Naked: isNakedGitUrl(projectUrl)}
+		// a URL keeps the transport it was given with
+		if req.Naked {
+			req.GitUrl, req.Transport = transports.Apply(req.GitUrl)
+		}
+		requests = append(requests, req)
+	}
+
+	// the same repo can't be got concurrently
+	unique := []getRequest{}
+	seen := map[string]bool{}
+	for _, req := range requests {
+		dir := getLocalDir(req.GitUrl)
+		if !seen[dir] {
+			seen[dir] = true
+			unique = append(unique, req)
+		}
+	}
+	return unique, nil
+}
+
+// getCanonicalRepo looks up repo with the provider's API, to find its current
+// name after renames and transfers. Repos on hosts without a known provider,
+// or that can't be looked up in time, e.g. private repos without credentials
+// in .netrc, return false and are got as given.
+func getCanonicalRepo(ctx context.Context, repo RepoName) (RemoteRepo, bool) {
+	provider, err := RepoProviderFor(repo.String())
+	if err != nil {
+		return RemoteRepo{}, false
+	}
+	ctx, cancel := context.WithTimeout(ctx, providerLookupTimeout)
+	defer cancel()
+	r, err := provider.GetRepo(ctx, repo.Path)
+	if err != nil {
+		return RemoteRepo{}, false
+	}
+	return r, true
+}
+
+// resolve fills in req from the provider's API, moving it to the repo's
+// current name if it was renamed. It's looked up just before getting, so
+// that lookups run concurrently, and not at all when getting an existing
+// repo without update, which leaves it as it is.
+func (o getOptions) resolve(ctx context.Context, req getRequest) (getRequest, error) {
+	if req.Listed || (!o.update && dirExists(getLocalDir(req.GitUrl))) {
+		return req, nil
+	}
+	given := repoNameFromGitUrl(req.GitUrl)
+	r, ok := getCanonicalRepo(ctx, given)
+	if !ok {
+		return req, nil
+	}
+	req.DefaultBranch, req.Archived = r.DefaultBranch, r.IsArchived
+
+	// names differing only in case are the same repo to the provider
+	if strings.EqualFold(r.RepoName.String(), given.String()) {
+		return req, nil
+	}
+	gitUrl, err := getGitUrl(remoteUrlWithTransportOf(req.GitUrl.String(), r.RepoName))
+	if err != nil {
+		return req, err
+	}
+	req.GitUrl, req.RenamedFrom = gitUrl, given
+	if req.Naked {
+		req.GitUrl, req.Transport = o.transports.Apply(req.GitUrl)
+	}
+	return req, nil
+}
+
+func (o getOptions) newGetCmdContext(req getRequest, stdout, stderr io.Writer, echo func(cmd, dir string)) *getCmdContext {
+	dir := getLocalDir(req.GitUrl)
+	c := &getCmdContext{
+		Stdout:       stdout,
+		Stderr:       stderr,
+		CmdEchoFunc:  echo,
+		WorkingDir:   dir,
+		Strategy:     o.strategies.For(dir),
+		Diverged:     o.diverged,
+		RestoreStash: o.restoreStash,
+		Transport:    req.Transport,
+
+		DefaultBranch: req.DefaultBranch,
+	}
+	if o.skipLfs {
+		c.Env = append(c.Env, lfsSkipSmudgeEnv)
+	}
+	return c
+}
+
+// checkGetRequest returns an error if req can't be got with the options
+func (o getOptions) checkGetRequest(req getRequest) error {
+	_, isReview := parseReviewRef(req.Ref)
+	if o.worktree && !isReview {
+		return fmt.Errorf("--worktree needs a pull or merge request, e.g. %s@pr/123", strings.TrimSuffix(req.Arg, "@"+req.Ref))
+	}
+	strategy := o.strategies.For(getLocalDir(req.GitUrl))
+	if o.update && req.Ref != "" && strategy != updateStrategyDefault {
+		return fmt.Errorf("can't checkout '%s' with the '%s' update strategy", req.Ref, strategy)
+	}
+	return nil
+}
+
+func (o getOptions) get(ctx context.Context, c *getCmdContext, req getRequest) error {
+	if req.Archived {
+		c.info(fmt.Sprintf("Warning: '%s' is archived", repoNameFromGitUrl(req.GitUrl)))
+	}
+	if req.RenamedFrom != (RepoName{}) {
+		msg := fmt.Sprintf("'%s' is now '%s'", req.RenamedFrom, repoNameFromGitUrl(req.GitUrl))
+		if dirExists(req.RenamedFrom.LocalPathAbsolute()) {
+			msg += ", use 'orgit sync --tidy' to move the existing clone"
+		}
+		c.info(msg)
+	}
+	if review, ok := parseReviewRef(req.Ref); ok {
+		return c.doGetReview(ctx, req.GitUrl, review, o.update, o.worktree)
+	}
+	return c.doGet(req.GitUrl, req.Ref, o.update)
+}
+
+// getFailure is a repo that couldn't be got
+type getFailure struct {
+	Dir string
+	Err error
+}
+
+// doGetAll gets the repos concurrently with the same worker pool size and
+// progress logging as sync, continuing past failures, which are returned
+func doGetAll(ctx context.Context, requests []getRequest, opts getOptions, logger *ProgressLogger) []getFailure {
+	mu := sync.Mutex{}
+	failures := []getFailure{}
+	claimed := map[string]bool{}
+	p := pool.New().WithMaxGoroutines(SyncWorkerPoolSize).WithContext(ctx)
+
+	logger.AddTotalToProgress(int32(len(requests)))
+	for _, req := range requests {
+		p.Go(func(ctx context.Context) error {
+			req, err := opts.resolve(ctx, req)
+			dir := getLocalDir(req.GitUrl)
+			if err != nil {
+				logger.EventSyncedRepoError(dir)
+				mu.Lock()
+				defer mu.Unlock()
+				failures = append(failures, getFailure{Dir: dir, Err: err})
+				return nil
+			}
+
+			// the same repo can't be got concurrently, e.g. by its old and new names
+			mu.Lock()
+			duplicate := claimed[dir]
+			claimed[dir] = true
+			mu.Unlock()
+			if duplicate {
+				logger.EventSkippedRepo(dir)
+				return nil
+			}
+
+			c := opts.newGetCmdContext(req, logger.WriterFor(dir), logger.WriterFor(dir), logger.EventExecCmd)
+			c.Progress = logger.IsDashboardEnabled()
+			c.InfoFunc = logger.Info
+
+			existed := dirExists(dir)
+			_, isReview := parseReviewRef(req.Ref)
+			logger.EventStartRepo(dir)
+			err = ctx.Err()
+			if err == nil {
+				err = opts.get(ctx, c, req)
+			}
+			switch {
+			case err != nil:
+				logger.EventSyncedRepoError(dir)
+				mu.Lock()
+				defer mu.Unlock()
+				failures = append(failures, getFailure{Dir: dir, Err: err})
+			case !existed:
+				logger.EventCheckoutExtras(c.SubmodulesUpdated, c.LfsPulled)
+				logger.EventClonedRepo(dir)
+			case c.DivergedSkipped:
+				logger.EventCheckoutExtras(c.SubmodulesUpdated, c.LfsPulled)
+				logger.EventDivergedRepo(dir)
+			case opts.update || isReview:
+				logger.EventCheckoutExtras(c.SubmodulesUpdated, c.LfsPulled)
+				logger.EventUpdatedRepo(dir, c.Strategy)
+			default:
+				logger.EventSkippedRepo(dir)
+			}
+			return nil
+		})
+	}
+	_ = p.Wait()
+
+	slices.SortFunc(failures, func(a, b getFailure) int {
+		return strings.Compare(a.Dir, b.Dir)
+	})
+	return failures
+}
@@ -0,0 +1,220 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSplitGlobPattern(t *testing.T) {
+	tests := map[string]string{
+		"github.com/my-org/*-service":     "github.com/my-org",
+		"gitlab.com/group/sub/api-?":      "gitlab.com/group/sub",
+		"gitlab.com/group/*/api":          "gitlab.com/group",
+		"github.com/my-org/[ab]*/":        "github.com/my-org",
+		"github.com/*/repo":               "",
+		"*.com/org/repo":                  "",
+		"github.com/my-org/service-*.git": "github.com/my-org",
+	}
+	for pattern, expected := range tests {
+		org, err := splitGlobPattern(pattern)
+		if expected == "" {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %s", pattern, org)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", pattern, err)
+		} else if org.String() != expected {
+			t.Errorf("%s: expected %s, got %s", pattern, expected, org)
+		}
+	}
+}
+
+func TestMatchRepoGlob(t *testing.T) {
+	repos := []RemoteRepo{}
+	for _, name := range []string{"github.com/org/b-service", "github.com/org/a-service", "github.com/org/web", "github.com/org/sub/c-service"} {
+		repos = append(repos, RemoteRepo{RepoName: MustParseRepoName(name)})
+	}
+
+	matched, err := matchRepoGlob("github.com/org/*-service", repos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, r := range matched {
+		names = append(names, r.RepoName.String())
+	}
+	if len(names) != 2 || names[0] != "github.com/org/a-service" || names[1] != "github.com/org/b-service" {
+		t.Errorf("expected the services directly in the org in order, got %v", names)
+	}
+
+	if _, err := matchRepoGlob("github.com/org/[", repos); err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
+
+func TestDoGetAll(t *testing.T) {
+	_, _ = newTestRepoWithRemote(t) // sets up the git environment
+	workspace := t.TempDir()
+	t.Setenv("ORGIT_WORKSPACE", workspace)
+
+	// serve example.com/org from local bare repos
+	remotes := t.TempDir()
+	for _, name := range []string{"a", "b"} {
+		gitInTest(t, remotes, "init", "--quiet", "--bare", "--initial-branch=main", name+".git")
+		clone := filepath.Join(t.TempDir(), name)
+		gitInTest(t, remotes, "clone", "--quiet", filepath.Join(remotes, name+".git"), clone)
+		gitInTest(t, clone, "commit", "--quiet", "--allow-empty", "-m", "initial")
+		gitInTest(t, clone, "push", "--quiet", "origin", "main")
+	}
+	gitInTest(t, remotes, "config", "--global", "url."+remotes+"/.insteadOf", "https://example.com/org/")
+
+	args := []string{"example.com/org/a", "example.com/org/missing", "example.com/org/b", "https://example.com/org/a.git"}
+	requests, err := resolveGetArgs(context.Background(), args, transportSelector{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 3 {
+		t.Fatalf("expected duplicate repos to be got once, got %d requests", len(requests))
+	}
+
+	failures := doGetAll(context.Background(), requests, getOptions{}, NewProgressLogger("quiet"))
+	if len(failures) != 1 || failures[0].Dir != filepath.Join(workspace, "example.com/org/missing") {
+		t.Errorf("expected only example.com/org/missing to fail, got %+v", failures)
+	}
+	for _, name := range []string{"a", "b"} {
+		if _, err := os.Stat(filepath.Join(workspace, "example.com/org", name, ".git")); err != nil {
+			t.Errorf("expected example.com/org/%s to be cloned past the failure: %s", name, err)
+		}
+	}
+}
+
+// testRepoProvider serves repos by the path they're looked up with
+type testRepoProvider struct {
+	genericRepoProvider
+	repos map[string]RemoteRepo
+}
+
+func newTestRepoProvider(t *testing.T, host string, repos map[string]RemoteRepo) {
+	t.Helper()
+	p := testRepoProvider{
+		genericRepoProvider: genericRepoProvider{prefix: host + "/", appendPrefix: "https://", appendSuffix: ".git"},
+		repos:               repos,
+	}
+	old := KnownGitProviders
+	KnownGitProviders = append([]RepoProvider{p}, old...)
+	t.Cleanup(func() { KnownGitProviders = old })
+}
+
+func (p testRepoProvider) ListRepos(ctx context.Context, org string, includeArchived bool, remoteRepoChan chan RemoteRepo) error {
+	for _, r := range p.repos {
+		if strings.HasPrefix(r.RepoName.Path, org+"/") && (includeArchived || !r.IsArchived) {
+			remoteRepoChan <- r
+		}
+	}
+	return nil
+}
+
+func (p testRepoProvider) GetRepo(ctx context.Context, repoName string) (RemoteRepo, error) {
+	r, ok := p.repos[repoName]
+	if !ok {
+		return RemoteRepo{}, ErrRepoNotFound
+	}
+	return r, nil
+}
+
+func (p testRepoProvider) GetPullRequest(ctx context.Context, repoName string, number int) (PullRequest, error) {
+	return PullRequest{}, ErrRepoNotFound
+}
+
+func (p testRepoProvider) SearchRepos(ctx context.Context, query string, limit int) ([]RemoteRepo, error) {
+	repos := []RemoteRepo{}
+	for _, r := range p.repos {
+		if strings.Contains(strings.ToLower(r.RepoName.Path), strings.ToLower(query)) {
+			repos = append(repos, r)
+		}
+	}
+	slices.SortFunc(repos, func(a, b RemoteRepo) int {
+		return strings.Compare(a.RepoName.String(), b.RepoName.String())
+	})
+	return repos, nil
+}
+
+func TestResolveGetArgsWithProvider(t *testing.T) {
+	workspace := t.TempDir()
+	t.Setenv("ORGIT_WORKSPACE", workspace)
+	renamed := RemoteRepo{
+		RepoName:      RepoName{Host: "example.org", Path: "neworg/new"},
+		CloneUrl:      "https://example.org/neworg/new.git",
+		DefaultBranch: "develop",
+		IsArchived:    true,
+	}
+	other := RemoteRepo{
+		RepoName:      RepoName{Host: "example.org", Path: "neworg/other"},
+		CloneUrl:      "https://example.org/neworg/other.git",
+		DefaultBranch: "main",
+	}
+	newTestRepoProvider(t, "example.org", map[string]RemoteRepo{"org/old": renamed, "neworg/new": renamed, "NewOrg/New": renamed, "neworg/other": other})
+	opts := getOptions{update: true}
+
+	tests := []struct {
+		arg      string
+		gitUrl   string
+		renamed  bool
+		upstream bool // found with the provider
+	}{
+		{"example.org/org/old", "https://example.org/neworg/new.git", true, true},
+		{"git@example.org:org/old.git@v1", "ssh://git@example.org/neworg/new.git", true, true},
+		{"example.org/neworg/new", "https://example.org/neworg/new.git", false, true},
+		{"example.org/NewOrg/New", "https://example.org/NewOrg/New.git", false, true},
+		{"example.org/org/missing", "https://example.org/org/missing.git", false, false},
+		{"example.com/org/repo", "https://example.com/org/repo.git", false, false},
+	}
+	for _, tt := range tests {
+		requests, err := resolveGetArgs(context.Background(), []string{tt.arg}, transportSelector{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := opts.resolve(context.Background(), requests[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if req.GitUrl.String() != tt.gitUrl {
+			t.Errorf("%s: expected %s, got %s", tt.arg, tt.gitUrl, req.GitUrl)
+		}
+		if (req.RenamedFrom != RepoName{}) != tt.renamed {
+			t.Errorf("%s: expected renamed %v, got %s", tt.arg, tt.renamed, req.RenamedFrom)
+		}
+		if (req.DefaultBranch == "develop" && req.Archived) != tt.upstream {
+			t.Errorf("%s: expected the default branch and archived state from the provider: %v", tt.arg, tt.upstream)
+		}
+	}
+
+	// an existing repo is left as it is without update, so isn't looked up
+	mkdirs(t, filepath.Join(workspace, "example.org/org/old"))
+	requests, err := resolveGetArgs(context.Background(), []string{"example.org/org/old"}, transportSelector{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := getOptions{}.resolve(context.Background(), requests[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.GitUrl.String() != "https://example.org/org/old.git" || req.DefaultBranch != "" {
+		t.Errorf("expected the existing repo not to be looked up, got %+v", req)
+	}
+
+	// globs only match unarchived repos
+	requests, err = resolveGetArgs(context.Background(), []string{"example.org/neworg/*"}, transportSelector{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 1 || requests[0].GitUrl.String() != other.CloneUrl || requests[0].DefaultBranch != "main" {
+		t.Errorf("expected only %s to match, got %+v", other.CloneUrl, requests)
+	}
+}
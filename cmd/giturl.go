@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// scpLikeUrlRe matches the scp-like syntax for ssh remotes, e.g.
+// git@github.com:org/repo.git, where there is a colon before the first slash
+var scpLikeUrlRe = regexp.MustCompile(`^(?:([^@/:]+)@)?([^@/:]+):(.+)$`)
+
+var hostnameRe = regexp.MustCompile(`^[a-zA-Z0-9-]+(\.[a-zA-Z0-9-]+)+$`)
+
+var gitUrlSchemes = []string{"https", "http", "ssh", "git", "git+ssh"}
+
+// scpLikeToUrl converts an scp-like ssh remote to an ssh:// URL
+func scpLikeToUrl(s string) (string, bool) {
+	if strings.Contains(s, "://") {
+		return "", false
+	}
+	m := scpLikeUrlRe.FindStringSubmatch(s)
+	if m == nil {
+		return "", false
+	}
+	userAt := ""
+	if m[1] != "" {
+		userAt = m[1] + "@"
+	}
+	return fmt.Sprintf("ssh://%s%s/%s", userAt, m[2], strings.TrimPrefix(m[3], "/")), true
+}
+
+// getGitUrl parses a repo given as an https, ssh, git protocol or scp-like
+// URL, or as a naked "host/path" which is cloned with https. The transport
+// of a URL is kept as given.
+func getGitUrl(origGitUrlStr string) (*url.URL, error) {
+	gitUrlStr := strings.TrimSuffix(origGitUrlStr, "/")
+
+	if sshUrl, ok := scpLikeToUrl(gitUrlStr); ok {
+		gitUrlStr = sshUrl
+	} else if !strings.Contains(gitUrlStr, "://") {
+		// normalise naked git URLs for known git providers
+		naked := strings.TrimSuffix(gitUrlStr, ".git")
+		gitUrlStr = fmt.Sprintf("https://%s.git", naked)
+		for _, provider := range KnownGitProviders {
+			if provider.IsMatch(naked) {
+				gitUrlStr = provider.NormaliseGitUrl(naked)
+				break
+			}
+		}
+	}
+
+	gitUrl, err := url.Parse(gitUrlStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid git url '%s'", origGitUrlStr)
+	}
+	if !slices.Contains(gitUrlSchemes, gitUrl.Scheme) {
+		return nil, fmt.Errorf("invalid git url '%s', unsupported transport '%s'", origGitUrlStr, gitUrl.Scheme)
+	}
+	if !hostnameRe.MatchString(gitUrl.Hostname()) || strings.Trim(gitUrl.Path, "/") == "" {
+		return nil, fmt.Errorf("invalid git url '%s', it isn't a hosted repository", origGitUrlStr)
+	}
+	return gitUrl, nil
+}
+
+// isNakedGitUrl returns true if s is a repo given as "host/path" rather
+// than as a URL with a transport
+func isNakedGitUrl(s string) bool {
+	_, ok := scpLikeToUrl(s)
+	return !ok && !strings.Contains(s, "://")
+}
+
+// getRemoteGitUrl parses the URL of a git remote. Unlike getGitUrl, naked
+// paths are not accepted as git treats them as local paths.
+func getRemoteGitUrl(remote string) (*url.URL, error) {
+	if isNakedGitUrl(remote) {
+		return nil, fmt.Errorf("can't parse remote url '%s'", remote)
+	}
+	return getGitUrl(remote)
+}
+
+// repoNameFromGitUrl returns the name of the repo at gitUrl, which is the
+// same for every transport
+func repoNameFromGitUrl(gitUrl *url.URL) RepoName {
+	return RepoName{
+		Host: strings.ToLower(gitUrl.Hostname()),
+		Path: strings.TrimSuffix(strings.Trim(gitUrl.Path, "/"), ".git"),
+	}
+}
+
+// remoteUrlWithTransportOf returns the URL of repo using the same transport,
+// user and port as the existing remote URL, in the same syntax
+func remoteUrlWithTransportOf(existing string, repo RepoName) string {
+	suffix := ""
+	if strings.HasSuffix(strings.TrimSuffix(existing, "/"), ".git") {
+		suffix = ".git"
+	}
+
+	if _, ok := scpLikeToUrl(existing); ok {
+		m := scpLikeUrlRe.FindStringSubmatch(existing)
+		userAt := ""
+		if m[1] != "" {
+			userAt = m[1] + "@"
+		}
+		return fmt.Sprintf("%s%s:%s%s", userAt, repo.Host, repo.Path, suffix)
+	}
+
+	u, err := url.Parse(existing)
+	if err != nil {
+		return repo.GitUrl()
+	}
+	port := u.Port()
+	u.Host = repo.Host
+	if port != "" {
+		u.Host = fmt.Sprintf("%s:%s", repo.Host, port)
+	}
+	u.Path = "/" + repo.Path + suffix
+	return u.String()
+}
+
+// splitRefFromGitUrl splits a trailing "@REF" from s. An "@" separating the
+// user from the host in an ssh URL isn't treated as a ref.
+func splitRefFromGitUrl(s string) (gitUrl, ref string) {
+	pathStart := 0
+	if i := strings.Index(s, "://"); i != -1 {
+		if slash := strings.Index(s[i+3:], "/"); slash != -1 {
+			pathStart = i + 3 + slash
+		} else {
+			pathStart = len(s)
+		}
+	} else if _, ok := scpLikeToUrl(s); ok {
+		pathStart = strings.Index(s, ":")
+	}
+
+	if at := strings.Index(s[pathStart:], "@"); at != -1 {
+		return s[:pathStart+at], s[pathStart+at+1:]
+	}
+	return s, ""
+}
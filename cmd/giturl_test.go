@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestParseArgsForGetCmdTransports(t *testing.T) {
+	t.Setenv("ORGIT_WORKSPACE", "/home/user/orgit")
+	const repoDir = "/home/user/orgit/github.com/org/repo"
+
+	tests := []struct {
+		arg         string
+		expectedUrl string
+		expectedRef string
+		expectedDir string
+	}{
+		// naked
+		{"github.com/org/repo", "https://github.com/org/repo.git", "", repoDir},
+		{"github.com/org/repo.git", "https://github.com/org/repo.git", "", repoDir},
+		{"github.com/org/repo/", "https://github.com/org/repo.git", "", repoDir},
+		{"github.com/org/repo@v1.2.3", "https://github.com/org/repo.git", "v1.2.3", repoDir},
+		{"github.com/org/repo@feature/x", "https://github.com/org/repo.git", "feature/x", repoDir},
+		{"example.org/group/sub/repo", "https://example.org/group/sub/repo.git", "", "/home/user/orgit/example.org/group/sub/repo"},
+
+		// https
+		{"https://github.com/org/repo", "https://github.com/org/repo", "", repoDir},
+		{"https://github.com/org/repo.git", "https://github.com/org/repo.git", "", repoDir},
+		{"https://github.com/org/repo.git/", "https://github.com/org/repo.git", "", repoDir},
+		{"https://user@github.com/org/repo.git@main", "https://user@github.com/org/repo.git", "main", repoDir},
+		{"https://GitHub.com/org/repo.git", "https://GitHub.com/org/repo.git", "", repoDir},
+
+		// scp-like ssh
+		{"git@github.com:org/repo.git", "ssh://git@github.com/org/repo.git", "", repoDir},
+		{"git@github.com:org/repo", "ssh://git@github.com/org/repo", "", repoDir},
+		{"git@github.com:/org/repo.git", "ssh://git@github.com/org/repo.git", "", repoDir},
+		{"git@github.com:org/repo.git@abc123", "ssh://git@github.com/org/repo.git", "abc123", repoDir},
+		{"github.com:org/repo.git", "ssh://github.com/org/repo.git", "", repoDir},
+
+		// ssh://
+		{"ssh://git@github.com/org/repo.git", "ssh://git@github.com/org/repo.git", "", repoDir},
+		{"ssh://git@github.com:22/org/repo.git", "ssh://git@github.com:22/org/repo.git", "", repoDir},
+		{"ssh://git@github.com/org/repo.git@main", "ssh://git@github.com/org/repo.git", "main", repoDir},
+		{"git+ssh://git@github.com/org/repo.git", "git+ssh://git@github.com/org/repo.git", "", repoDir},
+
+		// git://
+		{"git://github.com/org/repo.git", "git://github.com/org/repo.git", "", repoDir},
+	}
+
+	for _, tt := range tests {
+		gitUrl, ref, err := parseArgsForGetCmd(tt.arg)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.arg, err)
+			continue
+		}
+		if gitUrl.String() != tt.expectedUrl {
+			t.Errorf("%s: expected url %s, got %s", tt.arg, tt.expectedUrl, gitUrl)
+		}
+		if ref != tt.expectedRef {
+			t.Errorf("%s: expected ref '%s', got '%s'", tt.arg, tt.expectedRef, ref)
+		}
+		if dir := getLocalDir(gitUrl); dir != tt.expectedDir {
+			t.Errorf("%s: expected dir %s, got %s", tt.arg, tt.expectedDir, dir)
+		}
+	}
+}
+
+func TestGetGitUrlInvalid(t *testing.T) {
+	for _, arg := range []string{
+		"",
+		"repo",
+		"github.com",
+		"https://github.com/",
+		"file:///home/user/repo.git",
+		"ftp://github.com/org/repo.git",
+		"localhost:repo.git",
+		"../repo",
+	} {
+		if u, err := getGitUrl(arg); err == nil {
+			t.Errorf("'%s': expected an error, got %s", arg, u)
+		}
+	}
+}
+
+func TestGetRemoteGitUrl(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com/org/repo.git":    "github.com/org/repo",
+		"git@github.com:org/repo.git":        "github.com/org/repo",
+		"ssh://git@github.com:2222/org/repo": "github.com/org/repo",
+		"git://gitlab.com/group/sub/repo":    "gitlab.com/group/sub/repo",
+		"github.com/org/repo":                "",
+		"/srv/git/repo.git":                  "",
+		"file:///srv/git/repo.git":           "",
+	}
+	for remote, expected := range tests {
+		u, err := getRemoteGitUrl(remote)
+		if expected == "" {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %s", remote, u)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", remote, err)
+			continue
+		}
+		if r := repoNameFromGitUrl(u).String(); r != expected {
+			t.Errorf("%s: expected %s, got %s", remote, expected, r)
+		}
+	}
+}
+
+func TestRemoteUrlWithTransportOf(t *testing.T) {
+	renamed := RepoName{Host: "github.com", Path: "org/new"}
+	tests := map[string]string{
+		"https://github.com/org/old.git":        "https://github.com/org/new.git",
+		"https://github.com/org/old":            "https://github.com/org/new",
+		"git@github.com:org/old.git":            "git@github.com:org/new.git",
+		"github.com:org/old":                    "github.com:org/new",
+		"ssh://git@github.com:2222/org/old.git": "ssh://git@github.com:2222/org/new.git",
+		"git://github.com/org/old.git":          "git://github.com/org/new.git",
+	}
+	for existing, expected := range tests {
+		if actual := remoteUrlWithTransportOf(existing, renamed); actual != expected {
+			t.Errorf("%s: expected %s, got %s", existing, expected, actual)
+		}
+	}
+}
+
+func TestFixRemoteConfigKeepsTransport(t *testing.T) {
+	_, local := newTestRepoWithRemote(t)
+	c := getCmdContext{
+		Stdout:      io.Discard,
+		Stderr:      io.Discard,
+		CmdEchoFunc: func(cmd, dir string) {},
+		WorkingDir:  local,
+	}
+	originUrl := func() string {
+		out, err := c.doExec("git config --get remote.origin.url")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(out)
+	}
+
+	gitInTest(t, local, "remote", "set-url", "origin", "git@github.com:org/repo.git")
+	httpsUrl, _ := url.Parse("https://github.com/org/repo.git")
+	if err := c.fixRemoteConfig(httpsUrl); err != nil {
+		t.Fatal(err)
+	}
+	if actual := originUrl(); actual != "git@github.com:org/repo.git" {
+		t.Errorf("expected ssh origin to be kept, got %s", actual)
+	}
+
+	renamedUrl, _ := url.Parse("https://github.com/org/renamed.git")
+	if err := c.fixRemoteConfig(renamedUrl); err != nil {
+		t.Fatal(err)
+	}
+	if actual := originUrl(); actual != "git@github.com:org/renamed.git" {
+		t.Errorf("expected renamed repo to keep the ssh transport, got %s", actual)
+	}
+}
@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/fatih/color"
+	"github.com/mtibben/orgit/syncprinter"
+	"github.com/sourcegraph/conc/pool"
+	"github.com/spf13/cobra"
+)
+
+const defaultBranchTreeish = "origin/HEAD"
+
+// grepMatch is a single line matched by git grep
+type grepMatch struct {
+	Repo string `json:"repo"`
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+type grepOptions struct {
+	ignoreCase    bool
+	fixedStrings  bool
+	wordRegexp    bool
+	defaultBranch bool
+	json          bool
+}
+
+func (o grepOptions) gitArgs(pattern string, pathspecs []string) []string {
+	args := []string{"grep", "--line-number", "--null", "-I"}
+	if o.ignoreCase {
+		args = append(args, "--ignore-case")
+	}
+	if o.fixedStrings {
+		args = append(args, "--fixed-strings")
+	}
+	if o.wordRegexp {
+		args = append(args, "--word-regexp")
+	}
+	args = append(args, "-e", pattern)
+	if o.defaultBranch {
+		args = append(args, defaultBranchTreeish)
+	}
+	args = append(args, "--")
+	return append(args, pathspecs...)
+}
+
+// parseGitGrepLine parses a line of `git grep --line-number --null` output.
+// When searching a tree, paths are prefixed with the treeish.
+func parseGitGrepLine(line, treeish string) (filePath string, lineNum int, text string, ok bool) {
+	parts := strings.SplitN(line, "\x00", 3)
+	if len(parts) != 3 {
+		return "", 0, "", false
+	}
+	lineNum, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", 0, "", false
+	}
+	filePath = parts[0]
+	if treeish != "" {
+		filePath = strings.TrimPrefix(filePath, treeish+":")
+	}
+	return filePath, lineNum, parts[2], true
+}
+
+func (m grepMatch) String() string {
+	return fmt.Sprintf("%s:%s:%s", color.MagentaString(path.Join(m.Repo, m.Path)), color.GreenString("%d", m.Line), m.Text)
+}
+
+// grepRepo runs git grep in dir, printing each match as it is found. It
+// returns true if there were any matches.
+func grepRepo(dir, pattern string, pathspecs []string, opts grepOptions) (bool, error) {
+	c := exec.Command("git", opts.gitArgs(pattern, pathspecs)...)
+	c.Dir = dir
+	stderr := &strings.Builder{}
+	c.Stderr = stderr
+	stdout, err := c.StdoutPipe()
+	if err != nil {
+		return false, fmt.Errorf("couldn't get git grep output: %w", err)
+	}
+	err = c.Start()
+	if err != nil {
+		return false, fmt.Errorf("couldn't run git grep in '%s': %w", dir, err)
+	}
+
+	treeish := ""
+	if opts.defaultBranch {
+		treeish = defaultBranchTreeish
+	}
+	repo := relativeToWorkspace(dir)
+	found := false
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		filePath, lineNum, text, ok := parseGitGrepLine(scanner.Text(), treeish)
+		if !ok {
+			continue
+		}
+		found = true
+		m := grepMatch{Repo: repo, Path: filePath, Line: lineNum, Text: text}
+		if opts.json {
+			b, _ := json.Marshal(m)
+			syncprinter.Stdout.Println(string(b))
+		} else {
+			syncprinter.Stdout.Println(m.String())
+		}
+	}
+
+	err = c.Wait()
+	exitErr := &exec.ExitError{}
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && stderr.Len() == 0 {
+		return false, nil // no matches
+	}
+	if err != nil {
+		return found, fmt.Errorf("%s: git grep: %w: %s", repo, err, cleanString(stderr.String()))
+	}
+	return found, nil
+}
+
+func init() {
+	selector := repoSelector{}
+	opts := grepOptions{}
+	var dir string
+	var jobs int
+
+	var cmdGrep = &cobra.Command{
+		Use:   "grep [flags] PATTERN [PATHSPEC]...",
+		Short: "Search the contents of git repositories",
+		Long: `Search the contents of each git repository in the workspace using 'git grep'.
+
+Matches are printed as they are found, prefixed with the path of the file
+relative to the workspace. Repos matching .orgitignore are skipped.
+
+Exits with status 0 if any lines were matched, 1 if none were and 2 if
+an error occurred.
+
+Examples:
+  orgit grep --dir github.com/my-org 'TODO' -- '*.go'
+  orgit grep --default-branch --json 'deprecatedFunc'
+`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			pattern := args[0]
+			pathspecs := args[1:]
+
+			baseDir := getWorkspaceDir()
+			if dir != "" {
+				baseDir = resolveWorkspacePath(baseDir, dir)
+			}
+			if !dirExists(baseDir) {
+				cmd.PrintErrf("'%s' does not exist\n", baseDir)
+				os.Exit(2)
+			}
+
+			var found, failed atomic.Bool
+			p := pool.New().WithMaxGoroutines(jobs)
+			for _, repoDir := range selector.selectRepos(baseDir) {
+				p.Go(func() {
+					repoFound, err := grepRepo(repoDir, pattern, pathspecs, opts)
+					if repoFound {
+						found.Store(true)
+					}
+					if err != nil {
+						failed.Store(true)
+						syncprinter.Println(err.Error())
+					}
+				})
+			}
+			p.Wait()
+
+			switch {
+			case failed.Load():
+				os.Exit(2)
+			case !found.Load():
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmdGrep.Flags().StringVar(&dir, "dir", "", "Only search repos in this directory, e.g. github.com/my-org")
+	cmdGrep.Flags().StringArrayVar(&selector.filters, "filter", nil, "Only search repos whose path matches this gitignore-style pattern (can be repeated)")
+	cmdGrep.Flags().BoolVarP(&opts.ignoreCase, "ignore-case", "i", false, "Ignore case differences between the pattern and the files")
+	cmdGrep.Flags().BoolVarP(&opts.fixedStrings, "fixed-strings", "F", false, "Treat the pattern as a literal string rather than a regex")
+	cmdGrep.Flags().BoolVarP(&opts.wordRegexp, "word-regexp", "w", false, "Only match the pattern at word boundaries")
+	cmdGrep.Flags().BoolVar(&opts.defaultBranch, "default-branch", false, "Search the remote default branch (origin/HEAD) instead of the worktree")
+	cmdGrep.Flags().BoolVar(&opts.json, "json", false, "Output each match as a line of JSON")
+	addJobsFlag(cmdGrep, &jobs, "Number of repos to search concurrently")
+	rootCmd.AddCommand(cmdGrep)
+}
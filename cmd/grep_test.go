@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseGitGrepLine(t *testing.T) {
+	tests := []struct {
+		line         string
+		treeish      string
+		expectedPath string
+		expectedLine int
+		expectedText string
+		expectedOk   bool
+	}{
+		{"README\x001\x00hello world", "", "README", 1, "hello world", true},
+		{"dir/file.go\x0042\x00a\x00b", "", "dir/file.go", 42, "a\x00b", true},
+		{"origin/HEAD:dir/file.go\x007\x00text", "origin/HEAD", "dir/file.go", 7, "text", true},
+		{"dir/file.go\x00x\x00text", "", "", 0, "", false},
+		{"Binary file matches", "", "", 0, "", false},
+	}
+
+	for _, tt := range tests {
+		filePath, lineNum, text, ok := parseGitGrepLine(tt.line, tt.treeish)
+		if ok != tt.expectedOk || filePath != tt.expectedPath || lineNum != tt.expectedLine || text != tt.expectedText {
+			t.Errorf("parseGitGrepLine(%q) = %q, %d, %q, %v", tt.line, filePath, lineNum, text, ok)
+		}
+	}
+}
+
+func TestGrepGitArgs(t *testing.T) {
+	opts := grepOptions{ignoreCase: true, defaultBranch: true}
+	expected := []string{"grep", "--line-number", "--null", "-I", "--ignore-case", "-e", "-pattern", "origin/HEAD", "--", "*.go"}
+	if result := opts.gitArgs("-pattern", []string{"*.go"}); !slices.Equal(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+}
@@ -0,0 +1,371 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const stateDir = ".orgit"
+const historyFile = "history.jsonl"
+
+const (
+	historyActionClone   = "clone"
+	historyActionUpdate  = "update"
+	historyActionArchive = "archive"
+	historyActionSkip    = "skip"
+	historyActionTrash   = "trash"
+	historyActionMove    = "move"
+
+	// historyActionDiverged is an update that left a diverged branch as it was
+	historyActionDiverged = "diverged"
+)
+
+func getStateDir() string {
+	return filepath.Join(getWorkspaceDir(), stateDir)
+}
+
+// newRunID returns an ID that sorts by the time of the run. The milliseconds
+// and process ID keep runs started in the same second apart.
+func newRunID(t time.Time) string {
+	return fmt.Sprintf("%s-%d", t.UTC().Format("20060102T150405.000Z"), os.Getpid())
+}
+
+// historyEntry records the outcome of syncing a single repo
+type historyEntry struct {
+	RunID    string        `json:"run_id"`
+	Target   string        `json:"target"`
+	Repo     string        `json:"repo"`
+	Action   string        `json:"action"`
+	Strategy string        `json:"strategy,omitempty"`
+	Start    time.Time     `json:"start"`
+	End      time.Time     `json:"end"`
+	Duration time.Duration `json:"duration"`
+	OldHead  string        `json:"old_head,omitempty"`
+	NewHead  string        `json:"new_head,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+func (e historyEntry) Failed() bool {
+	return e.Error != ""
+}
+
+func (e historyEntry) Changed() bool {
+	return e.Action == historyActionClone || e.OldHead != e.NewHead
+}
+
+// historyRecorder appends history entries for a single sync run to the
+// workspace history file. A nil recorder discards all entries.
+type historyRecorder struct {
+	RunID  string
+	Target string
+
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+func openHistoryRecorder(runID, target string) (*historyRecorder, error) {
+	err := os.MkdirAll(getStateDir(), 0755)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create state dir: %w", err)
+	}
+	f, err := os.OpenFile(filepath.Join(getStateDir(), historyFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open history file: %w", err)
+	}
+	return &historyRecorder{
+		RunID:  runID,
+		Target: target,
+		file:   f,
+		enc:    json.NewEncoder(f),
+	}, nil
+}
+
+func gitHead(dir string) string {
+	if !dirExists(dir) {
+		return ""
+	}
+	out, err := doExecQuietWithOutput(dir, "git rev-parse HEAD")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// Begin starts an entry for the repo in localDir, recording its current HEAD
+func (h *historyRecorder) Begin(localDir string) *historyEntry {
+	if h == nil {
+		return &historyEntry{}
+	}
+	return &historyEntry{
+		RunID:   h.RunID,
+		Target:  h.Target,
+		Repo:    relativeToWorkspace(localDir),
+		Start:   time.Now(),
+		OldHead: gitHead(localDir),
+	}
+}
+
+// End completes and persists the entry. Entries without an action are not
+// recorded.
+func (h *historyRecorder) End(e *historyEntry, localDir string, err error) {
+	if h == nil || e.Action == "" {
+		return
+	}
+	e.End = time.Now()
+	e.Duration = e.End.Sub(e.Start)
+	switch e.Action {
+	case historyActionArchive, historyActionTrash, historyActionMove:
+		// moving a repo doesn't change its HEAD, and it may no longer be at localDir
+		e.NewHead = e.OldHead
+	default:
+		e.NewHead = gitHead(localDir)
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_ = h.enc.Encode(e)
+}
+
+// BeginMove starts an entry for a filesystem move made outside of the worker
+// pool, before the repo in oldLocalDir is moved
+func (h *historyRecorder) BeginMove(action, oldLocalDir string) *historyEntry {
+	e := h.Begin(oldLocalDir)
+	e.Action = action
+	return e
+}
+
+func (h *historyRecorder) Close() error {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.file.Close()
+}
+
+func readHistory() ([]historyEntry, error) {
+	f, err := os.Open(filepath.Join(getStateDir(), historyFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open history file: %w", err)
+	}
+	defer f.Close()
+
+	entries := []historyEntry{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e historyEntry
+		if json.Unmarshal(scanner.Bytes(), &e) == nil {
+			entries = append(entries, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("couldn't read history file: %w", err)
+	}
+
+	return entries, nil
+}
+
+// parseAge parses a duration like time.ParseDuration, but also accepts a
+// number of days such as "30d"
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration '%s'", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration '%s'", s)
+	}
+	return d, nil
+}
+
+// failingRepos returns the repos whose last n recorded syncs all failed
+func failingRepos(entries []historyEntry, n int) []string {
+	streaks := map[string]int{}
+	broken := map[string]bool{}
+
+	// walk backwards so only the most recent entries for each repo count
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.Action == historyActionTrash || e.Action == historyActionMove || broken[e.Repo] {
+			continue
+		}
+		if e.Failed() {
+			streaks[e.Repo]++
+		} else {
+			broken[e.Repo] = true
+		}
+	}
+
+	repos := []string{}
+	for repo, streak := range streaks {
+		if streak >= n {
+			repos = append(repos, repo)
+		}
+	}
+	slices.Sort(repos)
+	return repos
+}
+
+type historyFilter struct {
+	runID   string
+	lastRun bool
+	since   time.Duration
+	repo    string
+	changed bool
+	failed  bool
+}
+
+func (f historyFilter) apply(entries []historyEntry, now time.Time) []historyEntry {
+	runID := f.runID
+	if f.lastRun && len(entries) > 0 {
+		runID = entries[len(entries)-1].RunID
+	}
+
+	filtered := []historyEntry{}
+	for _, e := range entries {
+		if runID != "" && e.RunID != runID {
+			continue
+		}
+		if f.since > 0 && e.Start.Before(now.Add(-f.since)) {
+			continue
+		}
+		if f.repo != "" && !strings.Contains(e.Repo, f.repo) {
+			continue
+		}
+		if f.changed && !e.Changed() {
+			continue
+		}
+		if f.failed && !e.Failed() {
+			continue
+		}
+		filtered = append(filtered, e)
+	}
+	return filtered
+}
+
+func shortSha(sha string) string {
+	if len(sha) > 8 {
+		return sha[:8]
+	}
+	return sha
+}
+
+func printHistoryTable(entries []historyEntry) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RUN\tREPO\tACTION\tHEAD\tDURATION\tERROR")
+	for _, e := range entries {
+		head := shortSha(e.NewHead)
+		if e.OldHead != e.NewHead {
+			head = fmt.Sprintf("%s..%s", shortSha(e.OldHead), shortSha(e.NewHead))
+		}
+		action := e.Action
+		if e.Strategy != "" {
+			action = fmt.Sprintf("%s (%s)", e.Action, e.Strategy)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", e.RunID, e.Repo, action, head, e.Duration.Round(time.Millisecond), cleanString(e.Error))
+	}
+	w.Flush()
+}
+
+func printJson(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	err := enc.Encode(v)
+	if err != nil {
+		return fmt.Errorf("couldn't encode json: %w", err)
+	}
+	return nil
+}
+
+func init() {
+	filter := historyFilter{}
+	var sinceFlag string
+	var failingFlag int
+	var jsonFlag bool
+
+	var cmdHistory = &cobra.Command{
+		Use:   "history",
+		Short: "Show the history of synced repos",
+		Long: `Show the history of synced repos.
+
+Each sync records the action taken on every repo, its HEAD before and after,
+any error and how long it took, in $ORGIT_WORKSPACE/.orgit/history.jsonl
+
+Examples:
+  orgit history --last --changed   # what changed in the most recent sync
+  orgit history --since 24h        # everything synced in the last day
+  orgit history --failing 3        # repos that failed the last 3 syncs
+`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			var err error
+			if sinceFlag != "" {
+				filter.since, err = parseAge(sinceFlag)
+				if err != nil {
+					cmd.PrintErrln(err)
+					os.Exit(1)
+				}
+			}
+
+			entries, err := readHistory()
+			if err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+
+			if failingFlag > 0 {
+				repos := failingRepos(entries, failingFlag)
+				if jsonFlag {
+					err = printJson(repos)
+				} else {
+					for _, r := range repos {
+						fmt.Println(r)
+					}
+				}
+			} else {
+				entries = filter.apply(entries, time.Now())
+				if jsonFlag {
+					err = printJson(entries)
+				} else {
+					printHistoryTable(entries)
+				}
+			}
+			if err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmdHistory.Flags().StringVar(&filter.runID, "run", "", "Only show entries for the sync with this run ID")
+	cmdHistory.Flags().BoolVar(&filter.lastRun, "last", false, "Only show entries for the most recent sync")
+	cmdHistory.Flags().StringVar(&sinceFlag, "since", "", "Only show entries newer than this duration, e.g. 24h or 7d")
+	cmdHistory.Flags().StringVar(&filter.repo, "repo", "", "Only show entries for repos containing this string")
+	cmdHistory.Flags().BoolVar(&filter.changed, "changed", false, "Only show repos whose HEAD changed")
+	cmdHistory.Flags().BoolVar(&filter.failed, "failed", false, "Only show entries with errors")
+	cmdHistory.Flags().IntVar(&failingFlag, "failing", 0, "List repos that failed each of their last N syncs")
+	cmdHistory.Flags().BoolVar(&jsonFlag, "json", false, "Output as JSON")
+	rootCmd.AddCommand(cmdHistory)
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    time.Duration
+		expectError bool
+	}{
+		{"30d", 30 * 24 * time.Hour, false},
+		{"0d", 0, false},
+		{"36h", 36 * time.Hour, false},
+		{"90m", 90 * time.Minute, false},
+		{"d", 0, true},
+		{"1w", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tt := range tests {
+		result, err := parseAge(tt.input)
+		if tt.expectError {
+			if err == nil {
+				t.Errorf("parseAge(%q): expected an error but got none", tt.input)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseAge(%q): did not expect an error but got %v", tt.input, err)
+		}
+		if result != tt.expected {
+			t.Errorf("parseAge(%q) = %v, expected %v", tt.input, result, tt.expected)
+		}
+	}
+}
+
+func TestFailingRepos(t *testing.T) {
+	entries := []historyEntry{
+		{Repo: "github.com/org/a", Action: historyActionUpdate, Error: "boom"},
+		{Repo: "github.com/org/b", Action: historyActionUpdate},
+		{Repo: "github.com/org/c", Action: historyActionUpdate},
+		{Repo: "github.com/org/a", Action: historyActionUpdate, Error: "boom"},
+		{Repo: "github.com/org/b", Action: historyActionUpdate, Error: "boom"},
+		{Repo: "github.com/org/c", Action: historyActionUpdate, Error: "boom"},
+		{Repo: "github.com/org/a", Action: historyActionUpdate, Error: "boom"},
+		{Repo: "github.com/org/b", Action: historyActionUpdate, Error: "boom"},
+		{Repo: "github.com/org/c", Action: historyActionUpdate},
+	}
+
+	tests := []struct {
+		n        int
+		expected []string
+	}{
+		{1, []string{"github.com/org/a", "github.com/org/b"}},
+		{2, []string{"github.com/org/a", "github.com/org/b"}},
+		{3, []string{"github.com/org/a"}},
+		{4, []string{}},
+	}
+
+	for _, tt := range tests {
+		result := failingRepos(entries, tt.n)
+		if !slices.Equal(result, tt.expected) {
+			t.Errorf("failingRepos(%d) = %v, expected %v", tt.n, result, tt.expected)
+		}
+	}
+}
+
+func TestHistoryFilter(t *testing.T) {
+	now := time.Now()
+	entries := []historyEntry{
+		{RunID: "run1", Repo: "github.com/org/a", Action: historyActionUpdate, Start: now.Add(-48 * time.Hour), OldHead: "1", NewHead: "2"},
+		{RunID: "run2", Repo: "github.com/org/a", Action: historyActionUpdate, Start: now.Add(-time.Hour), OldHead: "2", NewHead: "2"},
+		{RunID: "run2", Repo: "github.com/org/b", Action: historyActionClone, Start: now.Add(-time.Hour), NewHead: "3"},
+		{RunID: "run2", Repo: "github.com/org/c", Action: historyActionUpdate, Start: now.Add(-time.Hour), Error: "boom"},
+	}
+
+	tests := []struct {
+		name     string
+		filter   historyFilter
+		expected []string
+	}{
+		{"no filter", historyFilter{}, []string{"run1 github.com/org/a", "run2 github.com/org/a", "run2 github.com/org/b", "run2 github.com/org/c"}},
+		{"last run changed", historyFilter{lastRun: true, changed: true}, []string{"run2 github.com/org/b"}},
+		{"since", historyFilter{since: 24 * time.Hour, repo: "org/a"}, []string{"run2 github.com/org/a"}},
+		{"run", historyFilter{runID: "run1"}, []string{"run1 github.com/org/a"}},
+		{"failed", historyFilter{failed: true}, []string{"run2 github.com/org/c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := []string{}
+			for _, e := range tt.filter.apply(entries, now) {
+				result = append(result, e.RunID+" "+e.Repo)
+			}
+			if !slices.Equal(result, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestNewRunID(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	ids := []string{
+		newRunID(start),
+		newRunID(start.Add(time.Millisecond)),
+		newRunID(start.Add(time.Second)),
+	}
+	if ids[0] == ids[1] {
+		t.Errorf("expected runs in the same second to have different IDs, got %s", ids[0])
+	}
+	if !slices.IsSorted(ids) {
+		t.Errorf("expected run IDs to sort by time, got %v", ids)
+	}
+}
+
+func TestHistoryRecorderMovedRepos(t *testing.T) {
+	_, local := newTestRepoWithRemote(t)
+	workspace := filepath.Dir(local)
+	t.Setenv("ORGIT_WORKSPACE", workspace)
+	h, err := openHistoryRecorder("run1", "github.com/org")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := h.BeginMove(historyActionMove, local)
+	time.Sleep(10 * time.Millisecond)
+	moved := filepath.Join(workspace, "moved")
+	err = os.Rename(local, moved)
+	h.End(e, moved, err)
+
+	e = h.Begin(moved)
+	e.Action = historyActionArchive
+	archived := filepath.Join(workspace, archiveDir, "moved")
+	mkdirs(t, filepath.Dir(archived))
+	err = os.Rename(moved, archived)
+	h.End(e, moved, err)
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := readHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %v", entries)
+	}
+	for _, e := range entries {
+		if e.OldHead == "" || e.Changed() {
+			t.Errorf("Expected %s of %s to keep its HEAD, got %q..%q", e.Action, e.Repo, e.OldHead, e.NewHead)
+		}
+	}
+	if entries[0].Duration < 10*time.Millisecond {
+		t.Errorf("Expected the move's duration to include the move, got %s", entries[0].Duration)
+	}
+}
@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	importActionMove     = "move"
+	importActionSymlink  = "symlink"
+	importActionConflict = "conflict"
+	importActionNoRemote = "no-remote"
+	importActionSkip     = "skip"
+)
+
+// importedRepo is a repo found outside the workspace and where it belongs in it
+type importedRepo struct {
+	Action string `json:"action"`
+	From   string `json:"from"`
+	To     string `json:"to,omitempty"`
+	Remote string `json:"remote,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+func (r importedRepo) String() string {
+	switch r.Action {
+	case importActionMove:
+		return fmt.Sprintf("move %s to %s", r.From, r.To)
+	case importActionSymlink:
+		return fmt.Sprintf("symlink %s to %s", r.To, r.From)
+	case importActionConflict:
+		return fmt.Sprintf("conflict %s: %s", r.From, r.Reason)
+	case importActionNoRemote:
+		return fmt.Sprintf("no remote %s: %s", r.From, r.Reason)
+	default:
+		return fmt.Sprintf("skip %s: %s", r.From, r.Reason)
+	}
+}
+
+// findReposToImport walks dir for git repos, not descending into repos or the
+// workspace, and skipping directories that can't be read
+func findReposToImport(dir string) ([]string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	workspace := filepath.Clean(getWorkspaceDir())
+
+	repos := []string{}
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return fs.SkipDir
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path == workspace {
+			return fs.SkipDir
+		}
+		if isGitRepo(path) {
+			repos = append(repos, path)
+			return fs.SkipDir
+		}
+		return nil
+	})
+	return repos, err
+}
+
+// planImport decides where each repo belongs in the workspace, detecting
+// repos without a usable remote, destinations that already exist and
+// repos that would be imported to the same place
+func planImport(repos []string, symlink bool) []importedRepo {
+	action := importActionMove
+	if symlink {
+		action = importActionSymlink
+	}
+
+	planned := []importedRepo{}
+	claimed := map[string]string{}
+	for _, repo := range repos {
+		r := importedRepo{From: repo}
+		if isWorktree(repo) {
+			r.Action, r.Reason = importActionSkip, "a worktree of another repo"
+			planned = append(planned, r)
+			continue
+		}
+
+		remote, err := doExecQuietWithOutput(repo, "git config --get remote.origin.url")
+		r.Remote = strings.TrimSpace(remote)
+		if err != nil || r.Remote == "" {
+			r.Action, r.Reason = importActionNoRemote, "no origin remote"
+			planned = append(planned, r)
+			continue
+		}
+		remoteUrl, err := getRemoteGitUrl(r.Remote)
+		if err != nil {
+			r.Action, r.Reason = importActionNoRemote, err.Error()
+			planned = append(planned, r)
+			continue
+		}
+		r.To = getLocalDir(remoteUrl)
+
+		if isSamePath(r.To, repo) {
+			r.Action, r.Reason = importActionSkip, "already in the workspace"
+		} else if _, err := os.Lstat(r.To); err == nil {
+			r.Action, r.Reason = importActionConflict, fmt.Sprintf("%s already exists", r.To)
+		} else if other, ok := claimed[r.To]; ok {
+			r.Action, r.Reason = importActionConflict, fmt.Sprintf("%s has the same remote", other)
+		} else {
+			r.Action = action
+			claimed[r.To] = repo
+		}
+		planned = append(planned, r)
+	}
+
+	slices.SortStableFunc(planned, func(a, b importedRepo) int {
+		return strings.Compare(a.From, b.From)
+	})
+	return planned
+}
+
+func isSamePath(a, b string) bool {
+	resolvedA, errA := filepath.EvalSymlinks(a)
+	resolvedB, errB := filepath.EvalSymlinks(b)
+	return errA == nil && errB == nil && resolvedA == resolvedB
+}
+
+func doImport(r importedRepo) error {
+	switch r.Action {
+	case importActionMove:
+		return osMove(r.From, r.To)
+	case importActionSymlink:
+		err := os.MkdirAll(filepath.Dir(r.To), 0755)
+		if err != nil {
+			return fmt.Errorf("couldn't create parent dir: %w", err)
+		}
+		return os.Symlink(r.From, r.To)
+	}
+	return nil
+}
+
+func init() {
+	var symlink bool
+	var dryRun bool
+	var jsonFlag bool
+
+	var cmdImport = &cobra.Command{
+		Use:   "import DIR...",
+		Short: "Move existing clones into the workspace",
+		Long: `Find git repositories in each DIR and move them into the workspace, at the
+location given by their origin remote, e.g. a clone of git@github.com:org/repo.git
+is moved to $ORGIT_WORKSPACE/github.com/org/repo
+
+Repositories are not moved if something already exists at their location in the
+workspace, or if another repository found has the same remote. Repositories
+without an origin remote that orgit understands are reported and left alone.
+
+Use --symlink to leave repositories where they are and link to them from the
+workspace instead, and --dry-run to preview the changes.
+`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			repos := []string{}
+			for _, dir := range args {
+				found, err := findReposToImport(dir)
+				if err != nil {
+					cmd.PrintErrln(err)
+					os.Exit(1)
+				}
+				repos = append(repos, found...)
+			}
+			planned := planImport(repos, symlink)
+
+			if dryRun {
+				if jsonFlag {
+					err := printJson(planned)
+					if err != nil {
+						cmd.PrintErrln(err)
+						os.Exit(1)
+					}
+					return
+				}
+				for _, r := range planned {
+					fmt.Printf("would %s\n", r)
+				}
+				return
+			}
+
+			lock, err := acquireWorkspaceLock(false, func(msg string) { cmd.PrintErrln(msg) })
+			if err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+			defer lock.Release()
+
+			failed := false
+			for i, r := range planned {
+				err := doImport(r)
+				if err != nil {
+					planned[i].Reason = err.Error()
+					failed = true
+					cmd.PrintErrf("couldn't %s: %s\n", r, err)
+					continue
+				}
+				if !jsonFlag {
+					fmt.Println(r)
+				}
+			}
+			if jsonFlag {
+				err = printJson(planned)
+				if err != nil {
+					cmd.PrintErrln(err)
+					failed = true
+				}
+			}
+
+			if failed {
+				lock.Release()
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmdImport.Flags().BoolVar(&symlink, "symlink", false, "Symlink repositories into the workspace instead of moving them")
+	cmdImport.Flags().BoolVar(&dryRun, "dry-run", false, "Print what would be imported without changing anything")
+	cmdImport.Flags().BoolVar(&jsonFlag, "json", false, "Output as JSON")
+	rootCmd.AddCommand(cmdImport)
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestImport(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+	workspace := t.TempDir()
+	t.Setenv("ORGIT_WORKSPACE", workspace)
+	code := t.TempDir()
+
+	newRepo := func(dir, remote string) string {
+		dir = filepath.Join(code, dir)
+		mkdirs(t, dir)
+		gitInTest(t, dir, "init", "--quiet")
+		if remote != "" {
+			gitInTest(t, dir, "remote", "add", "origin", remote)
+		}
+		return dir
+	}
+	https := newRepo("a", "https://github.com/org/a.git")
+	ssh := newRepo("nested/b", "git@github.com:org/b.git")
+	duplicate := newRepo("nested/b-copy", "https://github.com/org/b")
+	existing := newRepo("c", "https://github.com/org/c.git")
+	noRemote := newRepo("d", "")
+	localRemote := newRepo("e", "/srv/git/e.git")
+	mkdirs(t, filepath.Join(workspace, "github.com/org/c"))
+
+	repos, err := findReposToImport(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	planned := planImport(repos, false)
+
+	expected := map[string]string{
+		https:       importActionMove,
+		ssh:         importActionMove,
+		duplicate:   importActionConflict,
+		existing:    importActionConflict,
+		noRemote:    importActionNoRemote,
+		localRemote: importActionNoRemote,
+	}
+	if len(planned) != len(expected) {
+		t.Fatalf("expected %d repos, got %+v", len(expected), planned)
+	}
+	for _, r := range planned {
+		if r.Action != expected[r.From] {
+			t.Errorf("%s: expected %s, got %s", r.From, expected[r.From], r)
+		}
+	}
+
+	for _, r := range planImport([]string{https, ssh}, true) {
+		if err := doImport(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !isGitRepo(filepath.Join(workspace, "github.com/org/b")) {
+		t.Error("expected b to be symlinked into the workspace")
+	}
+	if !isGitRepo(ssh) {
+		t.Error("expected symlinked repo to be left in place")
+	}
+
+	found := []string{}
+	forEachGitDirIn(workspace, func(path string) { found = append(found, filepath.ToSlash(path)) })
+	if len(found) != 2 || found[0] != "github.com/org/a" || found[1] != "github.com/org/b" {
+		t.Errorf("expected symlinked repos to be listed, got %v", found)
+	}
+
+	// importing again finds them already in place
+	for _, r := range planImport([]string{https}, false) {
+		if r.Action != importActionSkip {
+			t.Errorf("expected %s to be skipped, got %s", r.From, r)
+		}
+	}
+
+	for _, r := range planImport([]string{duplicate}, false) {
+		if r.Action != importActionConflict {
+			t.Errorf("expected conflict, got %s", r)
+		}
+	}
+	os.Remove(filepath.Join(workspace, "github.com/org/a"))
+	for _, r := range planImport([]string{https}, false) {
+		if err := doImport(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if isGitRepo(https) || !isGitRepo(filepath.Join(workspace, "github.com/org/a")) {
+		t.Error("expected a to be moved into the workspace")
+	}
+}
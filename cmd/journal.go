@@ -0,0 +1,314 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const journalDir = "journal"
+const journalExt = ".jsonl"
+
+const (
+	moveKindArchive = "archive"
+	moveKindTrash   = "trash"
+	moveKindRename  = "rename"
+)
+
+// journalEntry records a directory moved by sync
+type journalEntry struct {
+	Kind  string    `json:"kind"`
+	From  string    `json:"from"`
+	To    string    `json:"to"`
+	Head  string    `json:"head,omitempty"`
+	Moved time.Time `json:"moved"`
+}
+
+// moveJournal records every filesystem move made during a sync run so that
+// it can be undone. A nil journal moves without recording.
+type moveJournal struct {
+	RunID    string
+	InfoFunc func(msg string)
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+func getJournalDir() string {
+	return filepath.Join(getStateDir(), journalDir)
+}
+
+func journalPath(runID string) string {
+	return filepath.Join(getJournalDir(), runID+journalExt)
+}
+
+func newMoveJournal(runID string, infoFunc func(msg string)) *moveJournal {
+	return &moveJournal{RunID: runID, InfoFunc: infoFunc}
+}
+
+// Move moves from to to, recording the move in the journal if it succeeds.
+// Only the move can fail, as it has happened whether or not it's recorded.
+func (j *moveJournal) Move(kind, from, to string) error {
+	err := osMove(from, to)
+	if err != nil || j == nil {
+		return err
+	}
+
+	err = j.record(journalEntry{
+		Kind:  kind,
+		From:  from,
+		To:    to,
+		Head:  gitHead(to),
+		Moved: time.Now(),
+	})
+	if err != nil {
+		j.InfoFunc(fmt.Sprintf("Moved '%s' to '%s' but it can't be undone: %s", from, to, err))
+	}
+	return nil
+}
+
+func (j *moveJournal) record(e journalEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	// the journal file is only created once something has been moved
+	if j.file == nil {
+		err := os.MkdirAll(getJournalDir(), 0755)
+		if err != nil {
+			return fmt.Errorf("couldn't create journal dir: %w", err)
+		}
+		j.file, err = os.OpenFile(journalPath(j.RunID), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("couldn't open journal: %w", err)
+		}
+	}
+
+	err := json.NewEncoder(j.file).Encode(e)
+	if err != nil {
+		return fmt.Errorf("couldn't write journal: %w", err)
+	}
+	return nil
+}
+
+func (j *moveJournal) Close() error {
+	if j == nil || j.file == nil {
+		return nil
+	}
+	return j.file.Close()
+}
+
+func readJournal(runID string) ([]journalEntry, error) {
+	f, err := os.Open(journalPath(runID))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no moves were recorded for run '%s'", runID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open journal: %w", err)
+	}
+	defer f.Close()
+
+	entries := []journalEntry{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e journalEntry
+		err := json.Unmarshal(scanner.Bytes(), &e)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't parse journal '%s': %w", journalPath(runID), err)
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("couldn't read journal: %w", err)
+	}
+	return entries, nil
+}
+
+// writeJournal replaces the journal for runID with entries, removing it if
+// there are none left to undo
+func writeJournal(runID string, entries []journalEntry) error {
+	if len(entries) == 0 {
+		err := os.Remove(journalPath(runID))
+		if err != nil {
+			return fmt.Errorf("couldn't remove journal: %w", err)
+		}
+		return nil
+	}
+
+	buf := bytes.Buffer{}
+	enc := json.NewEncoder(&buf)
+	for _, e := range entries {
+		err := enc.Encode(e)
+		if err != nil {
+			return fmt.Errorf("couldn't encode journal: %w", err)
+		}
+	}
+
+	// written to a temporary file first so the journal is never left partial
+	tmp := journalPath(runID) + ".tmp"
+	err := os.WriteFile(tmp, buf.Bytes(), 0644)
+	if err != nil {
+		return fmt.Errorf("couldn't write journal: %w", err)
+	}
+	return os.Rename(tmp, journalPath(runID))
+}
+
+// listJournals returns the IDs of runs with moves that can be undone, oldest first
+func listJournals() ([]string, error) {
+	files, err := os.ReadDir(getJournalDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldn't read journal dir: %w", err)
+	}
+
+	runIDs := []string{}
+	for _, f := range files {
+		if runID, ok := strings.CutSuffix(f.Name(), journalExt); ok {
+			runIDs = append(runIDs, runID)
+		}
+	}
+	slices.Sort(runIDs)
+	return runIDs, nil
+}
+
+// checkUndoable returns an error if reversing e could clobber something
+func checkUndoable(e journalEntry) error {
+	if _, err := os.Lstat(e.To); err != nil {
+		return fmt.Errorf("'%s' no longer exists", e.To)
+	}
+	if _, err := os.Lstat(e.From); err == nil {
+		return fmt.Errorf("'%s' already exists", e.From)
+	}
+	// only repos have a HEAD, tidy also trashes plain files and directories
+	if e.Head == "" {
+		return nil
+	}
+	if head := gitHead(e.To); head != e.Head {
+		return fmt.Errorf("'%s' has changed since it was moved, HEAD was %s and is now %s", e.To, shortSha(e.Head), shortSha(head))
+	}
+	return nil
+}
+
+// undoRun reverts the moves recorded for runID in reverse order. All moves
+// are checked before any are reverted, and nothing is moved if any of the
+// destinations have since changed. Each reverted move is dropped from the
+// journal, so a run that fails partway can be undone again later.
+func undoRun(runID string, onUndo func(e journalEntry)) error {
+	entries, err := readJournal(runID)
+	if err != nil {
+		return err
+	}
+
+	errs := []error{}
+	for _, e := range entries {
+		if err := checkUndoable(e); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("refusing to undo run '%s': %w", runID, errors.Join(errs...))
+	}
+	if len(entries) == 0 {
+		return writeJournal(runID, nil)
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		err := osMove(e.To, e.From)
+		if err != nil {
+			return fmt.Errorf("couldn't move '%s' back to '%s': %w", e.To, e.From, err)
+		}
+		switch e.Kind {
+		case moveKindArchive:
+			removeEmptyParents(filepath.Dir(e.To), filepath.Join(getWorkspaceDir(), archiveDir))
+		case moveKindTrash:
+			removeEmptyParents(filepath.Dir(e.To), getTrashDir())
+			_ = forgetTrashRecord(relativeToWorkspace(e.From))
+		}
+		onUndo(e)
+
+		err = writeJournal(runID, entries[:i])
+		if err != nil {
+			return fmt.Errorf("moved '%s' back to '%s' but couldn't update the journal: %w", e.To, e.From, err)
+		}
+	}
+	return nil
+}
+
+func init() {
+	var list bool
+	var wait bool
+
+	var cmdUndo = &cobra.Command{
+		Use:   "undo [RUN_ID]",
+		Short: "Undo the archive, trash and rename moves made by a sync",
+		Long: `Undo the archive, trash and rename moves made by a sync, by moving each
+directory back to where it was in reverse order. Defaults to the most recent
+sync that moved anything.
+
+Nothing is moved if any of the directories have since been changed or removed,
+or if something now exists at their original location.
+`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runIDs, err := listJournals()
+			if err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+
+			if list {
+				for _, runID := range runIDs {
+					entries, err := readJournal(runID)
+					if err != nil {
+						cmd.PrintErrln(err)
+						continue
+					}
+					fmt.Printf("%s\t%d %s\n", runID, len(entries), pluralise(len(entries), "move", "moves"))
+				}
+				return
+			}
+
+			var runID string
+			if len(args) == 1 {
+				runID = args[0]
+			} else if len(runIDs) > 0 {
+				runID = runIDs[len(runIDs)-1]
+			} else {
+				cmd.PrintErrln("no sync moves to undo")
+				os.Exit(1)
+			}
+
+			lock, err := acquireWorkspaceLock(wait, func(msg string) { cmd.PrintErrln(msg) })
+			if err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+			defer lock.Release()
+
+			err = undoRun(runID, func(e journalEntry) {
+				fmt.Printf("Moved '%s' back to '%s'\n", e.To, e.From)
+			})
+			if err != nil {
+				cmd.PrintErrln(err)
+				lock.Release()
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmdUndo.Flags().BoolVar(&list, "list", false, "List the syncs that can be undone")
+	cmdUndo.Flags().BoolVar(&wait, "wait", false, "Wait for another orgit process to release the workspace lock instead of failing")
+	rootCmd.AddCommand(cmdUndo)
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUndoRun(t *testing.T) {
+	workspace := t.TempDir()
+	t.Setenv("ORGIT_WORKSPACE", workspace)
+
+	archived := filepath.Join(workspace, "github.com/org/archived")
+	trashed := filepath.Join(workspace, "github.com/org/trashed")
+	renamed := filepath.Join(workspace, "github.com/org/oldname")
+	mkdirs(t, archived, trashed, renamed)
+
+	j := newMoveJournal("run1", func(string) {})
+	moves := []struct{ kind, from, to string }{
+		{moveKindArchive, archived, filepath.Join(workspace, archiveDir, "github.com/org/archived")},
+		{moveKindTrash, trashed, filepath.Join(getTrashDir(), "github.com/org/trashed")},
+		{moveKindRename, renamed, filepath.Join(workspace, "github.com/org/newname")},
+	}
+	for _, m := range moves {
+		if err := j.Move(m.kind, m.from, m.to); err != nil {
+			t.Fatal(err)
+		}
+	}
+	j.Close()
+
+	runIDs, err := listJournals()
+	if err != nil || len(runIDs) != 1 || runIDs[0] != "run1" {
+		t.Fatalf("expected journal for run1, got %v %v", runIDs, err)
+	}
+
+	// something now occupies an original location, so nothing should move
+	mkdirs(t, renamed)
+	err = undoRun("run1", func(journalEntry) {})
+	if err == nil {
+		t.Fatal("expected undo to be refused")
+	}
+	for _, m := range moves {
+		if !dirExists(m.to) {
+			t.Errorf("expected '%s' not to have been moved", m.to)
+		}
+	}
+
+	removeEmptyParents(renamed, workspace)
+	undone := 0
+	err = undoRun("run1", func(journalEntry) { undone++ })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if undone != len(moves) {
+		t.Errorf("expected %d moves to be undone, got %d", len(moves), undone)
+	}
+	for _, m := range moves {
+		if !dirExists(m.from) || dirExists(m.to) {
+			t.Errorf("expected '%s' to be moved back to '%s'", m.to, m.from)
+		}
+	}
+	if dirExists(filepath.Join(workspace, archiveDir, "github.com")) {
+		t.Error("expected empty archive dirs to be removed")
+	}
+
+	runIDs, _ = listJournals()
+	if len(runIDs) != 0 {
+		t.Errorf("expected journal to be removed after undo, got %v", runIDs)
+	}
+}
+
+func TestUndoRunTrashedFile(t *testing.T) {
+	workspace := t.TempDir()
+	t.Setenv("ORGIT_WORKSPACE", workspace)
+
+	from := filepath.Join(workspace, "github.com/.DS_Store")
+	to := filepath.Join(getTrashDir(), "github.com/.DS_Store")
+	mkdirs(t, filepath.Dir(from))
+	writeTestFile(t, from, "")
+
+	j := newMoveJournal("run1", func(string) {})
+	if err := j.Move(moveKindTrash, from, to); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+
+	err := undoRun("run1", func(journalEntry) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fileExists(from) {
+		t.Errorf("expected '%s' to be moved back", from)
+	}
+}
+
+func TestMoveJournalWriteFailure(t *testing.T) {
+	workspace := t.TempDir()
+	t.Setenv("ORGIT_WORKSPACE", workspace)
+
+	// a file where the journal dir should be
+	mkdirs(t, getStateDir())
+	writeTestFile(t, getJournalDir(), "")
+
+	from := filepath.Join(workspace, "github.com/org/repo")
+	to := filepath.Join(workspace, archiveDir, "github.com/org/repo")
+	mkdirs(t, from)
+
+	var warning string
+	j := newMoveJournal("run1", func(msg string) { warning = msg })
+	if err := j.Move(moveKindArchive, from, to); err != nil {
+		t.Fatalf("expected the move to succeed, got %s", err)
+	}
+	if !dirExists(to) {
+		t.Errorf("expected '%s' to be moved", from)
+	}
+	if warning == "" {
+		t.Error("expected a warning that the move can't be undone")
+	}
+}
+
+func TestUndoRunRetryAfterFailure(t *testing.T) {
+	workspace := t.TempDir()
+	t.Setenv("ORGIT_WORKSPACE", workspace)
+
+	archived := filepath.Join(workspace, "github.com/org/archived")
+	trashed := filepath.Join(workspace, "github.com/other/trashed")
+	renamed := filepath.Join(workspace, "github.com/org/oldname")
+	mkdirs(t, archived, trashed, renamed)
+
+	j := newMoveJournal("run1", func(string) {})
+	moves := []struct{ kind, from, to string }{
+		{moveKindArchive, archived, filepath.Join(workspace, archiveDir, "github.com/org/archived")},
+		{moveKindTrash, trashed, filepath.Join(getTrashDir(), "github.com/other/trashed")},
+		{moveKindRename, renamed, filepath.Join(workspace, "github.com/org/newname")},
+	}
+	for _, m := range moves {
+		if err := j.Move(m.kind, m.from, m.to); err != nil {
+			t.Fatal(err)
+		}
+	}
+	j.Close()
+
+	// a file where the trashed repo's parent dir was stops the second move
+	// back, after the rename has already been undone
+	blocker := filepath.Dir(trashed)
+	removeEmptyParents(blocker, workspace)
+	writeTestFile(t, blocker, "")
+
+	undone := []journalEntry{}
+	err := undoRun("run1", func(e journalEntry) { undone = append(undone, e) })
+	if err == nil {
+		t.Fatal("expected the undo to fail")
+	}
+	if len(undone) != 1 || undone[0].From != renamed || !dirExists(renamed) {
+		t.Fatalf("expected only the rename to be undone, got %v", undone)
+	}
+	remaining, err := readJournal("run1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 2 || remaining[0].From != archived || remaining[1].From != trashed {
+		t.Fatalf("expected the journal to keep the moves that weren't undone, got %v", remaining)
+	}
+
+	err = os.Remove(blocker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	undone = nil
+	err = undoRun("run1", func(e journalEntry) { undone = append(undone, e) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(undone) != 2 {
+		t.Errorf("expected the remaining 2 moves to be undone, got %v", undone)
+	}
+	for _, m := range moves {
+		if !dirExists(m.from) || dirExists(m.to) {
+			t.Errorf("expected '%s' to be moved back to '%s'", m.to, m.from)
+		}
+	}
+	if runIDs, _ := listJournals(); len(runIDs) != 0 {
+		t.Errorf("expected journal to be removed after undo, got %v", runIDs)
+	}
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// lfsSkipSmudgeEnv stops git-lfs downloading objects on checkout, leaving pointer files
+const lfsSkipSmudgeEnv = "GIT_LFS_SKIP_SMUDGE=1"
+
+var errLfsNotInstalled = errors.New("git-lfs is not installed")
+
+var isLfsInstalled = sync.OnceValue(func() bool {
+	return exec.Command("git", "lfs", "version").Run() == nil
+})
+
+// usesLfs returns true if the repo in dir tracks any files with git-lfs
+func usesLfs(dir string) bool {
+	attributes, err := os.ReadFile(filepath.Join(dir, ".gitattributes"))
+	return err == nil && strings.Contains(string(attributes), "filter=lfs")
+}
+
+// pullLfs downloads the LFS objects for the current checkout, returning false
+// if the repo doesn't use LFS
+func (c *getCmdContext) pullLfs() (bool, error) {
+	if !usesLfs(c.WorkingDir) {
+		return false, nil
+	}
+	if !isLfsInstalled() {
+		return false, fmt.Errorf("can't pull LFS objects: %w", errLfsNotInstalled)
+	}
+	err := c.echoEval(`git lfs pull`)
+	if err != nil {
+		return false, fmt.Errorf("error pulling LFS objects: %w", err)
+	}
+	return true, nil
+}
+
+// parseLfsLsFiles counts the files in the output of `git lfs ls-files` whose
+// objects haven't been downloaded, which are marked with '-' rather than '*'
+func parseLfsLsFiles(lines []string) (total, missing int) {
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		total++
+		if fields[1] == "-" {
+			missing++
+		}
+	}
+	return total, missing
+}
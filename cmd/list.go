@@ -17,6 +17,8 @@ import (
 var ignoreDirs []string = []string{
 	archiveDir, // compatibility with git-workspace
 	trashDir,
+	stateDir,
+	worktreesDir,
 }
 
 func cleanString(s string) string {
@@ -39,19 +41,27 @@ func doExecQuietWithOutput(dir string, shCmd string) (string, error) {
 	return singleLineOut, nil
 }
 
+func isDirty(dir string) (bool, error) {
+	out, err := doExecQuietWithOutput(dir, "git status --porcelain")
+	if err != nil {
+		return false, err
+	}
+	return len(out) > 0, nil
+}
+
 func printDirs(baseDir, dir string, printFullPath, flagDirty bool) {
 	fullDir := filepath.Join(baseDir, dir)
 	if printFullPath {
 		dir = fullDir
 	}
 	if flagDirty {
-		out, err := doExecQuietWithOutput(fullDir, "git status --porcelain")
+		dirty, err := isDirty(fullDir)
 		if err != nil {
 			syncprinter.Println(err.Error())
 			return
 		}
 
-		if len(out) > 0 {
+		if dirty {
 			fmt.Println(dir)
 		}
 	} else {
@@ -63,25 +73,55 @@ func init() {
 	var flagDirty bool
 	var printFullPath bool
 	var archived bool
+	var worktrees bool
 
 	var cmdList = &cobra.Command{
-		Use:   "list",
+		Use:   "list [DIR]",
 		Short: "List git repositories",
-		Long:  "List git repositories in DIR, or in the workspace path if DIR is not specified.",
-		Args:  cobra.NoArgs,
+		Long: `List git repositories in DIR, or in the workspace path if DIR is not specified.
+
+DIR can be a path relative to the workspace, or the URL of a repo in any form
+accepted by 'orgit get', e.g. git@github.com:org/repo.git`,
+		Args: cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			baseDir := getWorkspaceDir()
 			if archived {
 				baseDir = filepath.Join(baseDir, archiveDir)
 			}
+			searchDir := baseDir
+			if len(args) == 1 {
+				searchDir = resolveWorkspacePath(baseDir, args[0])
+			}
+			if !dirExists(searchDir) {
+				cmd.PrintErrf("'%s' does not exist\n", searchDir)
+				os.Exit(1)
+			}
 
 			wg := sync.WaitGroup{}
-			forEachGitDirIn(baseDir, func(relativeDir string) {
+			forEachGitDirIn(searchDir, func(relativeDir string) {
 				wg.Add(1)
 				go func() {
 					defer wg.Done()
 
-					printDirs(baseDir, relativeDir, printFullPath, flagDirty)
+					// print paths relative to the workspace even when searching DIR
+					relativeToBase := func(path string) string {
+						dir, err := filepath.Rel(baseDir, path)
+						if err != nil {
+							return path
+						}
+						return dir
+					}
+					repoDir := filepath.Join(searchDir, relativeDir)
+					printDirs(baseDir, relativeToBase(repoDir), printFullPath, flagDirty)
+
+					if worktrees && !isWorktree(repoDir) {
+						ws, _ := listWorktrees(repoDir)
+						for _, w := range ws {
+							if !w.Main {
+								printDirs(baseDir, relativeToBase(w.Path), printFullPath, flagDirty)
+							}
+						}
+					}
 				}()
 			})
 			wg.Wait()
@@ -91,6 +131,7 @@ func init() {
 	cmdList.Flags().BoolVar(&flagDirty, "dirty", false, "Filter by git directories with uncommitted changes")
 	cmdList.Flags().BoolVar(&printFullPath, "full-path", false, "Print the absolute path of each git directory")
 	cmdList.Flags().BoolVar(&archived, "archived", false, "List archived git directories")
+	cmdList.Flags().BoolVar(&worktrees, "worktrees", false, "Also list the worktrees of each git directory")
 	rootCmd.AddCommand(cmdList)
 }
 
@@ -101,15 +142,22 @@ func forEachGitDirIn(baseDir string, doFunc func(path string)) {
 			panic(err)
 		}
 
+		// repos symlinked into the workspace, e.g. by 'orgit import --symlink'
+		if d.Type()&fs.ModeSymlink != 0 {
+			if _, err := fs.Stat(fsys, filepath.Join(path, ".git")); err == nil {
+				doFunc(path)
+			}
+			return nil
+		}
+
 		if d.IsDir() {
 			if slices.Contains(ignoreDirs, d.Name()) {
 				return fs.SkipDir
 			}
 
-			if i, err := fs.Stat(fsys, filepath.Join(path, ".git")); err == nil {
-				if i.IsDir() {
-					doFunc(path)
-				}
+			// worktrees have a .git file rather than a directory
+			if _, err := fs.Stat(fsys, filepath.Join(path, ".git")); err == nil {
+				doFunc(path)
 				return fs.SkipDir
 			}
 		}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const lockFileName = "lock"
+
+var errLocked = errors.New("workspace is locked")
+
+// workspaceLock is an advisory lock on the workspace, preventing concurrent
+// orgit processes from modifying the same repos. The OS releases the lock if
+// the process exits without releasing it.
+type workspaceLock struct {
+	f *os.File
+}
+
+func lockHolderPid(f *os.File) string {
+	_, err := f.Seek(0, io.SeekStart)
+	if err != nil {
+		return ""
+	}
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return ""
+	}
+	pid := strings.TrimSpace(string(b))
+	if _, err := strconv.Atoi(pid); err != nil {
+		return ""
+	}
+	return pid
+}
+
+// acquireWorkspaceLock locks the workspace. If the workspace is already
+// locked by another process, it returns an error naming the holding PID
+// unless wait is true, in which case it calls onWait and blocks until the
+// lock is released.
+func acquireWorkspaceLock(wait bool, onWait func(msg string)) (*workspaceLock, error) {
+	err := os.MkdirAll(getStateDir(), 0755)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create state dir: %w", err)
+	}
+	path := filepath.Join(getStateDir(), lockFileName)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open lock file: %w", err)
+	}
+
+	err = tryLockFile(f)
+	if errors.Is(err, errLocked) {
+		holder := "another orgit process"
+		if pid := lockHolderPid(f); pid != "" {
+			holder = fmt.Sprintf("orgit process with PID %s", pid)
+		}
+		if !wait {
+			f.Close()
+			return nil, fmt.Errorf("%w by %s (%s), use --wait to wait for it to finish", errLocked, holder, path)
+		}
+		onWait(fmt.Sprintf("Waiting for %s to release the workspace lock...", holder))
+		err = lockFile(f)
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("couldn't lock '%s': %w", path, err)
+	}
+
+	// record our PID so that other processes can report who holds the lock
+	err = f.Truncate(0)
+	if err == nil {
+		_, err = f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+	if err != nil {
+		l := &workspaceLock{f: f}
+		l.Release()
+		return nil, fmt.Errorf("couldn't write lock file: %w", err)
+	}
+
+	return &workspaceLock{f: f}, nil
+}
+
+func (l *workspaceLock) Release() {
+	if l == nil {
+		return
+	}
+	_ = l.f.Truncate(0)
+	_ = unlockFile(l.f)
+	l.f.Close()
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWorkspaceLock(t *testing.T) {
+	t.Setenv("ORGIT_WORKSPACE", t.TempDir())
+
+	lock, err := acquireWorkspaceLock(false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = acquireWorkspaceLock(false, nil)
+	if !errors.Is(err, errLocked) {
+		t.Fatalf("Expected workspace to be locked, got %v", err)
+	}
+	if !strings.Contains(err.Error(), fmt.Sprintf("PID %d", os.Getpid())) {
+		t.Errorf("Expected error to name the holding PID, got %s", err)
+	}
+
+	acquired := make(chan *workspaceLock)
+	waitMsg := make(chan string, 1)
+	go func() {
+		l, err := acquireWorkspaceLock(true, func(msg string) { waitMsg <- msg })
+		if err != nil {
+			t.Error(err)
+		}
+		acquired <- l
+	}()
+
+	select {
+	case msg := <-waitMsg:
+		if !strings.Contains(msg, "Waiting") {
+			t.Errorf("Unexpected wait message %q", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected to wait for the lock")
+	}
+
+	lock.Release()
+
+	select {
+	case l := <-acquired:
+		l.Release()
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected to acquire the lock after it was released")
+	}
+}
@@ -0,0 +1,49 @@
+//go:build windows
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lock a single byte well past the PID written to the lock file, as locked
+// regions can't be read by other processes on windows
+const lockOffset = math.MaxInt32
+
+func lockFileEx(f *os.File, flags uint32) error {
+	ol := &windows.Overlapped{Offset: lockOffset}
+	return windows.LockFileEx(windows.Handle(f.Fd()), flags, 0, 1, 0, ol)
+}
+
+func tryLockFile(f *os.File) error {
+	err := lockFileEx(f, windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return errLocked
+	}
+	if err != nil {
+		return fmt.Errorf("LockFileEx: %w", err)
+	}
+	return nil
+}
+
+func lockFile(f *os.File) error {
+	err := lockFileEx(f, windows.LOCKFILE_EXCLUSIVE_LOCK)
+	if err != nil {
+		return fmt.Errorf("LockFileEx: %w", err)
+	}
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	ol := &windows.Overlapped{Offset: lockOffset}
+	err := windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, ol)
+	if err != nil {
+		return fmt.Errorf("UnlockFileEx: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	planActionClone   = "clone"
+	planActionUpdate  = "update"
+	planActionArchive = "archive"
+	planActionTrash   = "trash"
+	planActionMove    = "move"
+	planActionSkip    = "skip"
+
+	planActionPruneBranch = "prune-branch"
+)
+
+// plannedAction is an action sync would take in a dry run
+type plannedAction struct {
+	Action   string `json:"action"`
+	Path     string `json:"path"`
+	To       string `json:"to,omitempty"`
+	Url      string `json:"url,omitempty"`
+	Branch   string `json:"branch,omitempty"`
+	Strategy string `json:"strategy,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+func (a plannedAction) String() string {
+	switch a.Action {
+	case planActionClone:
+		return fmt.Sprintf("would clone %s into %s", a.Url, a.Path)
+	case planActionUpdate:
+		if a.Strategy != "" && a.Strategy != updateStrategyDefault {
+			return fmt.Sprintf("would update %s with the %s strategy", a.Path, a.Strategy)
+		}
+		if a.Branch == "" {
+			return fmt.Sprintf("would update %s to the remote HEAD", a.Path)
+		}
+		return fmt.Sprintf("would update %s to branch %s", a.Path, a.Branch)
+	case planActionArchive:
+		return fmt.Sprintf("would archive %s to %s", a.Path, a.To)
+	case planActionTrash:
+		return fmt.Sprintf("would trash %s to %s (%s)", a.Path, a.To, a.Reason)
+	case planActionPruneBranch:
+		return fmt.Sprintf("would delete branch %s in %s (%s)", a.Branch, a.Path, a.Reason)
+	case planActionMove:
+		return fmt.Sprintf("would move renamed repo %s to %s", a.Path, a.To)
+	default:
+		return fmt.Sprintf("would skip %s (%s)", a.Path, a.Reason)
+	}
+}
+
+// syncPlan collects the actions a dry run of sync would take
+type syncPlan struct {
+	mu      sync.Mutex
+	actions []plannedAction
+}
+
+func (p *syncPlan) Add(a plannedAction) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.actions = append(p.actions, a)
+}
+
+// Has returns true if action is planned for path
+func (p *syncPlan) Has(action, path string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.ContainsFunc(p.actions, func(a plannedAction) bool {
+		return a.Action == action && a.Path == path
+	})
+}
+
+// Actions returns the planned actions sorted by path
+func (p *syncPlan) Actions() []plannedAction {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	actions := slices.Clone(p.actions)
+	slices.SortStableFunc(actions, func(a, b plannedAction) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return actions
+}
+
+func (p *syncPlan) Print(asJson bool) error {
+	actions := p.Actions()
+	if asJson {
+		return printJson(actions)
+	}
+	for _, a := range actions {
+		fmt.Fprintln(os.Stdout, a.String())
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestPlannedActionString(t *testing.T) {
+	tests := []struct {
+		action   plannedAction
+		expected string
+	}{
+		{plannedAction{Action: planActionClone, Path: "github.com/org/a", Url: "https://github.com/org/a.git"}, "would clone https://github.com/org/a.git into github.com/org/a"},
+		{plannedAction{Action: planActionUpdate, Path: "github.com/org/a", Branch: "main"}, "would update github.com/org/a to branch main"},
+		{plannedAction{Action: planActionUpdate, Path: "github.com/org/a"}, "would update github.com/org/a to the remote HEAD"},
+		{plannedAction{Action: planActionArchive, Path: "github.com/org/a", To: ".archive/github.com/org/a"}, "would archive github.com/org/a to .archive/github.com/org/a"},
+		{plannedAction{Action: planActionTrash, Path: "github.com/org/a", To: ".trash/github.com/org/a", Reason: trashReasonNotFound}, "would trash github.com/org/a to .trash/github.com/org/a (not found on remote)"},
+		{plannedAction{Action: planActionMove, Path: "github.com/org/a", To: "github.com/org/b"}, "would move renamed repo github.com/org/a to github.com/org/b"},
+		{plannedAction{Action: planActionSkip, Path: "github.com/org/a", Reason: "--no-update"}, "would skip github.com/org/a (--no-update)"},
+	}
+
+	for _, tt := range tests {
+		if result := tt.action.String(); result != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, result)
+		}
+	}
+}
+
+func TestSyncPlanActionsSortedByPath(t *testing.T) {
+	p := &syncPlan{}
+	p.Add(plannedAction{Action: planActionUpdate, Path: "github.com/org/c"})
+	p.Add(plannedAction{Action: planActionClone, Path: "github.com/org/a"})
+	p.Add(plannedAction{Action: planActionTrash, Path: "github.com/org/b"})
+
+	actions := p.Actions()
+	for i, expected := range []string{"github.com/org/a", "github.com/org/b", "github.com/org/c"} {
+		if actions[i].Path != expected {
+			t.Errorf("Expected action %d to be for %s, got %s", i, expected, actions[i].Path)
+		}
+	}
+}
+
+// snapshotTree returns every path under dir along with the size and
+// modification time of files. Directories only have their path, as git
+// status briefly creates an index.lock in .git.
+func snapshotTree(t *testing.T, dir string) []string {
+	t.Helper()
+	tree := []string{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		if d.IsDir() {
+			tree = append(tree, rel)
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		tree = append(tree, fmt.Sprintf("%s %d %s", rel, info.Size(), info.ModTime()))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func TestPlanWork(t *testing.T) {
+	workspace := t.TempDir()
+	t.Setenv("ORGIT_WORKSPACE", workspace)
+	addRepo := func(name string) string {
+		_, local := newTestRepoWithRemote(t)
+		gitInTest(t, local, "remote", "set-head", "origin", "main")
+		dir := filepath.Join(workspace, name)
+		mkdirs(t, filepath.Dir(dir))
+		if err := os.Rename(local, dir); err != nil {
+			t.Fatal(err)
+		}
+		return dir
+	}
+
+	// behind its remote, so an update would fetch and merge
+	updateDir := addRepo("github.com/org/update")
+	gitInTest(t, updateDir, "commit", "--quiet", "--allow-empty", "-m", "second")
+	gitInTest(t, updateDir, "push", "--quiet", "origin", "main")
+	gitInTest(t, updateDir, "reset", "--quiet", "--hard", "HEAD~")
+	addRepo("github.com/org/archived")
+	addRepo("github.com/org/deleted")
+	addRepo("github.com/org/old-name")
+
+	repo := func(name string, archived bool) RemoteRepo {
+		return RemoteRepo{
+			RepoName:      MustParseRepoName("github.com/" + name),
+			CloneUrl:      "https://github.com/" + name + ".git",
+			IsArchived:    archived,
+			DefaultBranch: "main",
+		}
+	}
+	remoteRepos := map[string]RemoteRepo{
+		"org/update":   repo("org/update", false),
+		"org/archived": repo("org/archived", true),
+		"org/new":      repo("org/new", false),
+		"org/old-name": repo("org/new-name", false),
+	}
+	newTestRepoProvider(t, "github.com", remoteRepos)
+
+	before := snapshotTree(t, workspace)
+
+	execCmds := &bytes.Buffer{}
+	logger := &ProgressLogger{
+		LogExecCmd: true,
+		WriterFor:  func(localDir string) io.Writer { return execCmds },
+	}
+	plan := &syncPlan{}
+	workerPool := NewSyncReposWorkerPool(context.Background(), true, true, true, logger)
+	workerPool.plan = plan
+	for _, r := range remoteRepos {
+		workerPool.remoteReposChan <- r
+	}
+	close(workerPool.remoteReposChan)
+	if err := workerPool.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	tidier := TidyAction{
+		repoProvider: KnownGitProviders[0],
+		logger:       logger,
+		plan:         plan,
+		remoteRepos:  workerPool.getAllRemoteRepos(),
+	}
+	tidier.Tidy(context.Background(), MustParseRepoName("github.com/org"))
+
+	expected := []plannedAction{
+		{Action: planActionArchive, Path: "github.com/org/archived", To: ".archive/github.com/org/archived"},
+		{Action: planActionTrash, Path: "github.com/org/deleted", To: ".trash/github.com/org/deleted", Reason: trashReasonNotFound},
+		{Action: planActionClone, Path: "github.com/org/new", Url: "https://github.com/org/new.git"},
+		{Action: planActionClone, Path: "github.com/org/new-name", Url: "https://github.com/org/new-name.git"},
+		{Action: planActionSkip, Path: "github.com/org/old-name", Reason: "renamed to github.com/org/new-name which will be cloned"},
+		{Action: planActionUpdate, Path: "github.com/org/update", Branch: "main", Strategy: updateStrategyDefault},
+	}
+	if actions := plan.Actions(); !slices.Equal(actions, expected) {
+		t.Errorf("Expected plan\n%v\ngot\n%v", expected, actions)
+	}
+
+	if execCmds.Len() > 0 {
+		t.Errorf("Expected no commands to be run, got %q", execCmds.String())
+	}
+	if after := snapshotTree(t, workspace); !slices.Equal(before, after) {
+		t.Errorf("Expected the workspace to be unchanged, before:\n%v\nafter:\n%v", before, after)
+	}
+}
+
+func TestSyncJsonNeedsDryRun(t *testing.T) {
+	t.Setenv("ORGIT_WORKSPACE", t.TempDir())
+	err := doSync(context.Background(), "github.com/org", syncOptions{logLevel: "quiet", json: true, diverged: divergedPolicyWarn})
+	if err == nil || !strings.Contains(err.Error(), "--json can only be used with --dry-run") {
+		t.Errorf("Expected --json without --dry-run to be rejected, got %v", err)
+	}
+}
@@ -20,12 +20,16 @@ const ansiSaveCursorPosition = "\033[s"
 const ansiClearLine = "\033[u\033[K"
 
 type ProgressLogger struct {
-	Printer             *syncprinter.Printer
-	WriterFor           func(localDir string) io.Writer
-	LogSyncedRepo       bool
-	LogExecCmd          bool
-	LogRealtimeProgress bool
-	LogInfo             bool
+	Printer       *syncprinter.Printer
+	WriterFor     func(localDir string) io.Writer
+	LogSyncedRepo bool
+	LogExecCmd    bool
+	LogInfo       bool
+	Activity      string // shown in the progress line, defaults to "Syncing"
+
+	// LogRealtimeProgress is read by the dashboard ticker while the sync
+	// goroutine may be ending the progress line, so it's atomic
+	LogRealtimeProgress atomic.Bool
 
 	statsTotal           atomic.Int32
 	statsComplete        atomic.Int32
@@ -33,9 +37,14 @@ type ProgressLogger struct {
 	statsIgnoredArchived atomic.Int32
 	statsErrors          atomic.Int32
 	statsArchived        atomic.Int32
+	statsDiverged        atomic.Int32
+	statsSubmodules      atomic.Int32
+	statsLfsPulled       atomic.Int32
 
 	stateProgressLineRunning bool
-	doneMsg                  string
+	doneMsg                  atomic.Pointer[string]
+
+	dashboard *dashboard
 }
 
 func NewProgressLogger(logLevel string) *ProgressLogger {
@@ -52,13 +61,14 @@ func NewProgressLogger(logLevel string) *ProgressLogger {
 			LogInfo: true,
 		}
 	case "verbose":
-		return &ProgressLogger{
-			Printer:             syncprinter.NewPrinter(os.Stderr),
-			WriterFor:           func(localDir string) io.Writer { return io.Discard },
-			LogSyncedRepo:       true,
-			LogRealtimeProgress: true,
-			LogInfo:             true,
+		p := &ProgressLogger{
+			Printer:       syncprinter.NewPrinter(os.Stderr),
+			WriterFor:     func(localDir string) io.Writer { return io.Discard },
+			LogSyncedRepo: true,
+			LogInfo:       true,
 		}
+		p.LogRealtimeProgress.Store(true)
+		return p
 
 	case "quiet":
 		return &ProgressLogger{
@@ -66,27 +76,77 @@ func NewProgressLogger(logLevel string) *ProgressLogger {
 			WriterFor: func(localDir string) io.Writer { return io.Discard },
 		}
 	default:
-		return &ProgressLogger{
-			Printer:             syncprinter.NewPrinter(os.Stderr),
-			WriterFor:           func(localDir string) io.Writer { return io.Discard },
-			LogRealtimeProgress: true,
-			LogInfo:             true,
+		p := &ProgressLogger{
+			Printer:   syncprinter.NewPrinter(os.Stderr),
+			WriterFor: func(localDir string) io.Writer { return io.Discard },
+			LogInfo:   true,
 		}
+		p.LogRealtimeProgress.Store(true)
+		return p
+	}
+}
+
+// EnableDashboard replaces the single progress line with a multi-line view
+// of the repos currently being synced. It has no effect unless realtime
+// progress is being logged.
+func (p *ProgressLogger) EnableDashboard() {
+	if !p.LogRealtimeProgress.Load() {
+		return
+	}
+	p.dashboard = newDashboard()
+	p.WriterFor = func(localDir string) io.Writer {
+		return &gitProgressWriter{
+			onProgress: func(detail string) { p.dashboard.setDetail(localDir, detail) },
+		}
+	}
+}
+
+// IsDashboardEnabled returns true if git progress output should be requested
+func (p *ProgressLogger) IsDashboardEnabled() bool {
+	return p.dashboard != nil
+}
+
+func relativeToWorkspace(localDir string) string {
+	relDir, err := filepath.Rel(getWorkspaceDir(), localDir)
+	if err != nil {
+		return localDir
 	}
+	return relDir
 }
 
 func prefix(localDir string) string {
-	relDir, _ := filepath.Rel(getWorkspaceDir(), localDir)
-	return color.HiBlackString("%s ", relDir)
+	return color.HiBlackString("%s ", relativeToWorkspace(localDir))
 }
 
 func (p *ProgressLogger) EventExecCmd(cmd, dir string) {
+	if p.dashboard != nil {
+		p.dashboard.setPhase(dir, gitPhase(cmd))
+		p.PrintProgressLine()
+	}
 	if p.LogExecCmd {
 		w := p.WriterFor(dir)
 		fmt.Fprintln(w, color.CyanString("+ %s", cmd))
 	}
 }
 
+func (p *ProgressLogger) EventStartRepo(localDir string) {
+	if p.dashboard != nil {
+		p.dashboard.start(localDir)
+	}
+}
+
+func (p *ProgressLogger) finishRepo(localDir string) {
+	if p.dashboard != nil {
+		p.dashboard.finish(localDir)
+	}
+}
+
+func (p *ProgressLogger) logSyncedRepo(action, localDir string) {
+	if p.LogSyncedRepo {
+		p.printAboveProgressLine(fmt.Sprintf("%s %s", action, localDir))
+	}
+}
+
 func (p *ProgressLogger) AddTotalToProgress(n int32) {
 	p.statsTotal.Add(n)
 	p.PrintProgressLine()
@@ -94,29 +154,53 @@ func (p *ProgressLogger) AddTotalToProgress(n int32) {
 
 func (p *ProgressLogger) EventArchivedRepo(localDir string) {
 	p.statsArchived.Add(1)
-	if p.LogSyncedRepo {
-		p.Printer.Printf("%sarchived %s\n%s", ansiClearLine, localDir, ansiSaveCursorPosition)
-	}
+	p.finishRepo(localDir)
+	p.logSyncedRepo("archived", localDir)
 	p.PrintProgressLine()
 }
 
+// EventCheckoutExtras counts repos whose submodules were updated or LFS objects pulled
+func (p *ProgressLogger) EventCheckoutExtras(submodulesUpdated, lfsPulled bool) {
+	if submodulesUpdated {
+		p.statsSubmodules.Add(1)
+	}
+	if lfsPulled {
+		p.statsLfsPulled.Add(1)
+	}
+}
+
 func (p *ProgressLogger) EventSyncedRepoError(localDir string) {
 	p.statsErrors.Add(1)
+	p.finishRepo(localDir)
 }
 
-func (p *ProgressLogger) EventUpdatedRepo(localDir string) {
+func (p *ProgressLogger) EventUpdatedRepo(localDir, strategy string) {
 	p.statsComplete.Add(1)
-	if p.LogSyncedRepo {
-		p.Printer.Printf("%supdated %s\n%s", ansiClearLine, localDir, ansiSaveCursorPosition)
-	}
+	p.finishRepo(localDir)
+	p.logSyncedRepo(fmt.Sprintf("updated (%s)", strategy), localDir)
 	p.PrintProgressLine()
 }
 
 func (p *ProgressLogger) EventSkippedRepo(localDir string) {
 	p.statsComplete.Add(1)
-	if p.LogSyncedRepo {
-		p.Printer.Printf("%sskipped %s\n%s", ansiClearLine, localDir, ansiSaveCursorPosition)
-	}
+	p.finishRepo(localDir)
+	p.logSyncedRepo("skipped", localDir)
+	p.PrintProgressLine()
+}
+
+// EventDivergedRepo counts a repo that wasn't updated because its branch had diverged
+func (p *ProgressLogger) EventDivergedRepo(localDir string) {
+	p.statsComplete.Add(1)
+	p.statsDiverged.Add(1)
+	p.finishRepo(localDir)
+	p.logSyncedRepo("diverged", localDir)
+	p.PrintProgressLine()
+}
+
+func (p *ProgressLogger) EventPlannedRepo(localDir string) {
+	p.statsComplete.Add(1)
+	p.finishRepo(localDir)
+	p.logSyncedRepo("planned", localDir)
 	p.PrintProgressLine()
 }
 
@@ -128,21 +212,32 @@ func (p *ProgressLogger) EventIgnoredRepo(localDir string) {
 func (p *ProgressLogger) EventIgnoredArchivedRepo(localDir string) {
 	p.statsIgnoredArchived.Add(1)
 	p.statsTotal.Add(-1)
+	p.finishRepo(localDir)
 	p.PrintProgressLine()
 }
 
 func (p *ProgressLogger) EventClonedRepo(localDir string) {
 	p.statsComplete.Add(1)
-	if p.LogSyncedRepo {
-		p.Printer.Printf("%scloned %s\n%s", ansiClearLine, localDir, ansiSaveCursorPosition)
-	}
+	p.finishRepo(localDir)
+	p.logSyncedRepo("cloned", localDir)
 	p.PrintProgressLine()
 }
 
 func (p *ProgressLogger) EndProgressLine(doneMsg string) {
-	p.doneMsg = fmt.Sprintf(" %s\n", doneMsg)
+	msg := fmt.Sprintf(" %s\n", doneMsg)
+	if p.dashboard != nil {
+		// renders racing with this are erased by collapsing, so only the
+		// summary line has the done message
+		p.dashboard.stop()
+		if p.LogRealtimeProgress.Swap(false) && p.statsTotal.Load() > 0 {
+			// collapse the dashboard into the normal summary line
+			p.dashboard.collapse(p.Printer, p.progressLineStr()+msg)
+		}
+		return
+	}
+	p.doneMsg.Store(&msg)
 	p.PrintProgressLine()
-	p.LogRealtimeProgress = false
+	p.LogRealtimeProgress.Store(false)
 	p.stateProgressLineRunning = false
 }
 
@@ -175,6 +270,16 @@ func (p *ProgressLogger) Info(s string) {
 		return
 	}
 
+	p.printAboveProgressLine(s)
+	p.PrintProgressLine()
+}
+
+func (p *ProgressLogger) printAboveProgressLine(s string) {
+	if p.dashboard != nil && p.LogRealtimeProgress.Load() && p.statsTotal.Load() > 0 {
+		p.dashboard.render(p.Printer, s+"\n", p.progressLineStr(), time.Now())
+		return
+	}
+
 	firstChar := ""
 	lastChar := ""
 	if p.stateProgressLineRunning {
@@ -182,23 +287,40 @@ func (p *ProgressLogger) Info(s string) {
 		lastChar = ansiSaveCursorPosition
 	}
 	p.Printer.Printf("%s%s\n%s", firstChar, s, lastChar)
-	p.PrintProgressLine()
 }
 
 func (p *ProgressLogger) PrintProgressLine() {
-	if p.LogRealtimeProgress {
+	if p.LogRealtimeProgress.Load() {
 		total := p.statsTotal.Load()
 		if total > 0 {
+			if p.dashboard != nil {
+				p.dashboard.startTicker(p.PrintProgressLine)
+				p.dashboard.render(p.Printer, "", p.progressLineStr(), time.Now())
+				return
+			}
+
 			firstChar := ansiClearLine
 			if !p.stateProgressLineRunning {
 				firstChar = ansiSaveCursorPosition
 			}
 			p.stateProgressLineRunning = true
-			p.Printer.Printf("%sSyncing repos... %d/%d%s%s", firstChar, p.statsComplete.Load(), total, p.statsStr(), p.doneMsg)
+			p.Printer.Printf("%s%s", firstChar, p.progressLineStr())
 		}
 	}
 }
 
+func (p *ProgressLogger) progressLineStr() string {
+	activity := p.Activity
+	if activity == "" {
+		activity = "Syncing"
+	}
+	doneMsg := ""
+	if msg := p.doneMsg.Load(); msg != nil {
+		doneMsg = *msg
+	}
+	return fmt.Sprintf("%s repos... %d/%d%s%s", activity, p.statsComplete.Load(), p.statsTotal.Load(), p.statsStr(), doneMsg)
+}
+
 func (p *ProgressLogger) statsStr() string {
 	stats := []string{}
 
@@ -206,6 +328,18 @@ func (p *ProgressLogger) statsStr() string {
 	if numArchived >= 1 {
 		stats = append(stats, fmt.Sprintf("%d archived", numArchived))
 	}
+	numDiverged := p.statsDiverged.Load()
+	if numDiverged >= 1 {
+		stats = append(stats, fmt.Sprintf("%d diverged", numDiverged))
+	}
+	numSubmodules := p.statsSubmodules.Load()
+	if numSubmodules >= 1 {
+		stats = append(stats, fmt.Sprintf("%d with submodules", numSubmodules))
+	}
+	numLfsPulled := p.statsLfsPulled.Load()
+	if numLfsPulled >= 1 {
+		stats = append(stats, fmt.Sprintf("%d LFS pulled", numLfsPulled))
+	}
 	numErrors := p.statsErrors.Load()
 	if numErrors == 1 {
 		stats = append(stats, "1 error")
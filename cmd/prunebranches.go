@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/mtibben/orgit/syncprinter"
+	"github.com/sourcegraph/conc/pool"
+	"github.com/spf13/cobra"
+)
+
+const pruneReasonGone = "upstream is gone"
+
+// prunableBranch is a local branch that can be deleted without losing work
+type prunableBranch struct {
+	Repo   string `json:"repo"`
+	Branch string `json:"branch"`
+	Reason string `json:"reason"`
+}
+
+func (b prunableBranch) String() string {
+	return fmt.Sprintf("branch %s in %s (%s)", b.Branch, b.Repo, b.Reason)
+}
+
+// findPrunableBranches returns the local branches in dir whose upstream is gone
+// or which are fully merged into the remote default branch. Branches without an
+// upstream, or whose upstream is the remote default branch, only count as
+// merged once they've had commits of their own. The current branch, the
+// default branch and branches with commits that aren't on any remote are never
+// included.
+func findPrunableBranches(dir string) ([]prunableBranch, error) {
+	repo := relativeToWorkspace(dir)
+
+	defaultBranch, err := doExecQuietLines(dir, "git symbolic-ref --short refs/remotes/origin/HEAD")
+	if err != nil || len(defaultBranch) != 1 {
+		return nil, fmt.Errorf("can't find the default branch of '%s', try 'git remote set-head origin --auto'", repo)
+	}
+	remoteDefault := defaultBranch[0]
+
+	// an empty result means HEAD is detached
+	current, _ := doExecQuietLines(dir, "git symbolic-ref --quiet --short HEAD")
+
+	merged, err := doExecQuietLines(dir, fmt.Sprintf("git for-each-ref --format='%%(refname:short)' --merged %s refs/heads", shellQuote(remoteDefault)))
+	if err != nil {
+		return nil, err
+	}
+
+	branches, err := doExecQuietLines(dir, "git for-each-ref --format='%(refname:short)%09%(upstream)%09%(upstream:track)' refs/heads")
+	if err != nil {
+		return nil, err
+	}
+
+	prunable := []prunableBranch{}
+	for _, line := range branches {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		branch, upstream, track := fields[0], fields[1], fields[2]
+		if slices.Contains(current, branch) || "origin/"+branch == remoteDefault {
+			continue
+		}
+
+		reason := ""
+		if track == "[gone]" {
+			reason = pruneReasonGone
+		} else if slices.Contains(merged, branch) {
+			// a branch that was just created from the default branch is
+			// "merged" too, so unless it tracks a branch of its own it needs
+			// commits of its own
+			if upstream == "" || upstream == "refs/remotes/"+remoteDefault {
+				committed, err := hasCommitsSinceCreated(dir, branch)
+				if err != nil {
+					return nil, err
+				}
+				if !committed {
+					continue
+				}
+			}
+			reason = fmt.Sprintf("merged into %s", remoteDefault)
+		} else {
+			continue
+		}
+
+		unpushed, err := doExecQuietLines(dir, fmt.Sprintf("git rev-list --count %s --not --remotes", shellQuote("refs/heads/"+branch)))
+		if err != nil {
+			return nil, err
+		}
+		if len(unpushed) == 1 {
+			if n, _ := strconv.Atoi(unpushed[0]); n > 0 {
+				continue
+			}
+		}
+
+		prunable = append(prunable, prunableBranch{Repo: repo, Branch: branch, Reason: reason})
+	}
+	return prunable, nil
+}
+
+// hasCommitsSinceCreated returns true if branch has moved since it was
+// created, according to its reflog. Without a reflog it's assumed not to have.
+func hasCommitsSinceCreated(dir, branch string) (bool, error) {
+	reflog, err := doExecQuietLines(dir, fmt.Sprintf("git reflog show --format=%%H %s --", shellQuote("refs/heads/"+branch)))
+	if err != nil {
+		return false, err
+	}
+	if len(reflog) == 0 {
+		return false, nil
+	}
+	// the reflog is newest first, so the last entry is where it was created
+	return reflog[0] != reflog[len(reflog)-1], nil
+}
+
+func deleteBranch(dir string, b prunableBranch) error {
+	// -D as the branch has already been checked for unpushed commits, and
+	// branches with a gone upstream aren't considered merged by -d
+	_, err := doExecQuietWithOutput(dir, fmt.Sprintf("git branch -D %s", shellQuote(b.Branch)))
+	if err != nil {
+		return fmt.Errorf("couldn't delete %s: %w", b, err)
+	}
+	return nil
+}
+
+// pruneBranches fetches with --prune, then deletes the prunable branches in
+// dir, or only finds them if dryRun is set
+func pruneBranches(dir string, fetch, dryRun bool) ([]prunableBranch, error) {
+	if fetch && !dryRun {
+		_, err := doExecQuietWithOutput(dir, "git fetch --prune origin")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	prunable, err := findPrunableBranches(dir)
+	if err != nil || dryRun {
+		return prunable, err
+	}
+
+	for i, b := range prunable {
+		err := deleteBranch(dir, b)
+		if err != nil {
+			return prunable[:i], err
+		}
+	}
+	return prunable, nil
+}
+
+func init() {
+	selector := repoSelector{}
+	var dryRun bool
+	var noFetch bool
+	var jobs int
+
+	var cmdPruneBranches = &cobra.Command{
+		Use:   "prune-branches [DIR]",
+		Short: "Delete local branches that are merged or whose upstream is gone",
+		Long: `Delete local branches in each git repository in DIR, or in the workspace path if
+DIR is not specified, whose upstream branch has been deleted or which are fully
+merged into the remote default branch. A branch without an upstream, or that
+tracks the remote default branch, only counts as merged once it has had commits
+of its own, so new branches are kept.
+
+Each repository is first fetched with --prune. The current branch, the default
+branch and branches with commits that aren't on any remote are never deleted,
+so branches that were squash merged are kept.
+
+Use --dry-run to preview the branches that would be deleted, without fetching.
+`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			baseDir := getWorkspaceDir()
+			if len(args) == 1 {
+				baseDir = resolveWorkspacePath(baseDir, args[0])
+			}
+			if !dirExists(baseDir) {
+				cmd.PrintErrf("'%s' does not exist\n", baseDir)
+				os.Exit(1)
+			}
+
+			if !dryRun {
+				lock, err := acquireWorkspaceLock(false, func(msg string) { cmd.PrintErrln(msg) })
+				if err != nil {
+					cmd.PrintErrln(err)
+					os.Exit(1)
+				}
+				defer lock.Release()
+			}
+
+			verb := "Deleted"
+			if dryRun {
+				verb = "Would delete"
+			}
+
+			mu := sync.Mutex{}
+			failed := 0
+			p := pool.New().WithMaxGoroutines(jobs)
+			for _, dir := range selector.selectRepos(baseDir) {
+				p.Go(func() {
+					pruned, err := pruneBranches(dir, !noFetch, dryRun)
+					for _, b := range pruned {
+						syncprinter.Println(fmt.Sprintf("%s %s", verb, b))
+					}
+					if err != nil {
+						syncprinter.Println(color.RedString("%s: %s", relativeToWorkspace(dir), err))
+						mu.Lock()
+						defer mu.Unlock()
+						failed++
+					}
+				})
+			}
+			p.Wait()
+
+			if failed > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmdPruneBranches.Flags().BoolVar(&dryRun, "dry-run", false, "Print the branches that would be deleted without deleting them")
+	cmdPruneBranches.Flags().BoolVar(&noFetch, "no-fetch", false, "Don't fetch with --prune first")
+	cmdPruneBranches.Flags().StringArrayVar(&selector.filters, "filter", nil, "Only prune repos whose path matches this gitignore-style pattern (can be repeated)")
+	addJobsFlag(cmdPruneBranches, &jobs, "Number of repos to prune concurrently")
+	rootCmd.AddCommand(cmdPruneBranches)
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestPruneBranches(t *testing.T) {
+	remote, local := newTestRepoWithRemote(t)
+	gitInTest(t, local, "remote", "set-head", "origin", "main")
+	t.Setenv("ORGIT_WORKSPACE", filepath.Dir(local))
+
+	// committed to, then merged into main without an upstream
+	gitInTest(t, local, "checkout", "--quiet", "-b", "merged")
+	gitInTest(t, local, "commit", "--quiet", "--allow-empty", "-m", "merged")
+	gitInTest(t, local, "push", "--quiet", "origin", "merged:main")
+
+	// just created from main without an upstream, so not merged yet
+	gitInTest(t, local, "checkout", "--quiet", "main")
+	gitInTest(t, local, "branch", "new")
+	gitInTest(t, local, "checkout", "--quiet", "-b", "new-checkout")
+	gitInTest(t, local, "checkout", "--quiet", "main")
+
+	// just created tracking the default branch, so not merged yet either
+	gitInTest(t, local, "checkout", "--quiet", "-b", "new-tracking", "origin/main")
+	gitInTest(t, local, "checkout", "--quiet", "main")
+
+	// pushed, merged, then deleted on the remote
+	gitInTest(t, local, "checkout", "--quiet", "--no-track", "-b", "gone", "origin/main")
+	gitInTest(t, local, "commit", "--quiet", "--allow-empty", "-m", "gone")
+	gitInTest(t, local, "push", "--quiet", "--set-upstream", "origin", "gone")
+	gitInTest(t, local, "push", "--quiet", "origin", "gone:main")
+	gitInTest(t, local, "push", "--quiet", "origin", "--delete", "gone")
+
+	// upstream deleted, but with a commit that was never pushed
+	gitInTest(t, local, "checkout", "--quiet", "-b", "gone-unpushed", "main")
+	gitInTest(t, local, "commit", "--quiet", "--allow-empty", "-m", "pushed")
+	gitInTest(t, local, "push", "--quiet", "--set-upstream", "origin", "gone-unpushed")
+	gitInTest(t, local, "commit", "--quiet", "--allow-empty", "-m", "unpushed")
+	gitInTest(t, remote, "branch", "-D", "gone-unpushed")
+
+	// still has an upstream and isn't merged
+	gitInTest(t, local, "checkout", "--quiet", "-b", "active", "main")
+	gitInTest(t, local, "commit", "--quiet", "--allow-empty", "-m", "active")
+	gitInTest(t, local, "push", "--quiet", "--set-upstream", "origin", "active")
+
+	// the current branch is never deleted, even if merged
+	gitInTest(t, local, "checkout", "--quiet", "-b", "current", "main")
+
+	preview, err := pruneBranches(local, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	branchNames := func(branches []prunableBranch) []string {
+		names := []string{}
+		for _, b := range branches {
+			names = append(names, b.Branch)
+		}
+		slices.Sort(names)
+		return names
+	}
+	// a dry run doesn't fetch, so only the branch deleted with a push is known to be gone
+	if actual := branchNames(preview); !slices.Equal(actual, []string{"gone", "merged"}) {
+		t.Errorf("Expected dry run to find [gone merged], got %v", actual)
+	}
+
+	pruned, err := pruneBranches(local, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := branchNames(pruned); !slices.Equal(actual, []string{"gone", "merged"}) {
+		t.Errorf("Expected [gone merged] to be pruned, got %v", actual)
+	}
+
+	remaining, err := doExecQuietLines(local, "git for-each-ref --format='%(refname:short)' refs/heads")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"active", "current", "gone-unpushed", "main", "new", "new-checkout", "new-tracking"}; !slices.Equal(remaining, expected) {
+		t.Errorf("Expected branches %v to remain, got %v", expected, remaining)
+	}
+}
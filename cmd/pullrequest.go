@@ -0,0 +1,311 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	reviewKindPullRequest  = "pr"
+	reviewKindMergeRequest = "mr"
+)
+
+var reviewRefRe = regexp.MustCompile(`^(pr|mr)/([1-9][0-9]*)$`)
+
+// reviewRef is a GitHub pull request or GitLab merge request given as the
+// ref of a repo, e.g. github.com/org/repo@pr/123 or gitlab.com/group/repo@mr/45
+type reviewRef struct {
+	Kind   string
+	Number int
+}
+
+func parseReviewRef(ref string) (reviewRef, bool) {
+	m := reviewRefRe.FindStringSubmatch(ref)
+	if m == nil {
+		return reviewRef{}, false
+	}
+	n, _ := strconv.Atoi(m[2])
+	return reviewRef{Kind: m[1], Number: n}, true
+}
+
+// Branch is the name of the local branch for the review
+func (r reviewRef) Branch() string {
+	return fmt.Sprintf("%s/%d", r.Kind, r.Number)
+}
+
+// RemoteRef is the ref of the review's head on the target repo
+func (r reviewRef) RemoteRef() string {
+	if r.Kind == reviewKindMergeRequest {
+		return fmt.Sprintf("refs/merge-requests/%d/head", r.Number)
+	}
+	return fmt.Sprintf("refs/pull/%d/head", r.Number)
+}
+
+// TrackingRef is where the review's head is fetched to. It mirrors the remote
+// ref so it can't clash with the remote-tracking ref of a branch.
+func (r reviewRef) TrackingRef() string {
+	return "refs/remotes/origin/" + strings.TrimPrefix(r.RemoteRef(), "refs/")
+}
+
+// forkRemoteName returns the name of the remote for a review's source repo,
+// e.g. "alice" for github.com/alice/repo
+func forkRemoteName(source RepoName) string {
+	return strings.ReplaceAll(path.Dir(source.Path), "/", "-")
+}
+
+func (c *getCmdContext) branchExists(branch string) bool {
+	_, err := c.doExec(fmt.Sprintf(`git rev-parse --quiet --verify %s`, shellQuote("refs/heads/"+branch)))
+	return err == nil
+}
+
+// addFetchRefspec adds refspec to the fetch config of remote, unless it's
+// already there
+func (c *getCmdContext) addFetchRefspec(remote, refspec string) error {
+	refspecs, _ := doExecQuietLines(c.WorkingDir, fmt.Sprintf(`git config --get-all %s`, shellQuote("remote."+remote+".fetch")))
+	for _, r := range refspecs {
+		if r == refspec {
+			return nil
+		}
+	}
+	_, err := c.doExec(fmt.Sprintf(`git config --add %s %s`, shellQuote("remote."+remote+".fetch"), shellQuote(refspec)))
+	return err
+}
+
+// setUpstream makes branch track mergeRef on remote
+func (c *getCmdContext) setUpstream(branch, remote, mergeRef string) error {
+	_, err := c.doExec(fmt.Sprintf(`git config %s %s && git config %s %s`,
+		shellQuote("branch."+branch+".remote"), shellQuote(remote),
+		shellQuote("branch."+branch+".merge"), shellQuote(mergeRef)))
+	if err != nil {
+		return fmt.Errorf("error setting the upstream of '%s': %w", branch, err)
+	}
+	return nil
+}
+
+// createReviewBranch fetches the head of the review into a new branch that
+// tracks it, so fetching origin and updating the branch work like any other.
+// If the target repo doesn't have the review's ref, the source repo and
+// branch are looked up with the provider's API and added as a remote.
+func (c *getCmdContext) createReviewBranch(ctx context.Context, gitUrl *url.URL, r reviewRef) error {
+	refspec := fmt.Sprintf("+%s:%s", r.RemoteRef(), r.TrackingRef())
+	err := c.echoEvalf(`git fetch%s origin %s`, c.progressFlag(), shellQuote(refspec))
+	if err != nil {
+		repo := repoNameFromGitUrl(gitUrl)
+		pr, apiErr := getPullRequest(ctx, repo, r.Number)
+		if apiErr != nil {
+			return fmt.Errorf("couldn't fetch %s and couldn't find its source: %w", r.RemoteRef(), apiErr)
+		}
+		return c.createForkReviewBranch(r, pr)
+	}
+
+	// only added once the ref is known to exist, as fetching a missing ref fails
+	err = c.addFetchRefspec("origin", refspec)
+	if err != nil {
+		return fmt.Errorf("error adding refspec for %s: %w", r.RemoteRef(), err)
+	}
+	err = c.echoEvalf(`git branch --no-track %s %s`, shellQuote(r.Branch()), shellQuote(r.TrackingRef()))
+	if err != nil {
+		return fmt.Errorf("error creating branch '%s': %w", r.Branch(), err)
+	}
+	return c.setUpstream(r.Branch(), "origin", r.RemoteRef())
+}
+
+// createForkReviewBranch fetches the source branch of the review from its
+// repo, using the same transport as origin
+func (c *getCmdContext) createForkReviewBranch(r reviewRef, pr PullRequest) error {
+	remote := forkRemoteName(pr.SourceRepo)
+	sourceUrl := pr.SourceUrl
+	if originUrl, err := c.doExec(`git config --get remote.origin.url`); err == nil {
+		if _, err := getRemoteGitUrl(originUrl); err == nil {
+			sourceUrl = remoteUrlWithTransportOf(originUrl, pr.SourceRepo)
+		}
+	}
+
+	existingUrl, err := c.doExec(fmt.Sprintf(`git config --get %s`, shellQuote("remote."+remote+".url")))
+	if err != nil {
+		err = c.echoEvalf(`git remote add --no-tags -t %s %s %s`, shellQuote(pr.SourceBranch), shellQuote(remote), shellQuote(sourceUrl))
+		if err != nil {
+			return fmt.Errorf("error adding remote '%s': %w", remote, err)
+		}
+	} else if existingUrl != sourceUrl && existingUrl != pr.SourceUrl {
+		return fmt.Errorf("can't add remote '%s' for %s, it already exists with url '%s'", remote, pr.SourceRepo, existingUrl)
+	} else {
+		err = c.addFetchRefspec(remote, fmt.Sprintf("+refs/heads/%s:refs/remotes/%s/%s", pr.SourceBranch, remote, pr.SourceBranch))
+		if err != nil {
+			return fmt.Errorf("error adding refspec for '%s': %w", pr.SourceBranch, err)
+		}
+	}
+
+	err = c.echoEvalf(`git fetch%s %s`, c.progressFlag(), shellQuote(remote))
+	if err != nil {
+		return fmt.Errorf("error fetching '%s' from %s: %w", pr.SourceBranch, pr.SourceRepo, err)
+	}
+	err = c.echoEvalf(`git branch --no-track %s %s`, shellQuote(r.Branch()), shellQuote("refs/remotes/"+remote+"/"+pr.SourceBranch))
+	if err != nil {
+		return fmt.Errorf("error creating branch '%s': %w", r.Branch(), err)
+	}
+	return c.setUpstream(r.Branch(), remote, "refs/heads/"+pr.SourceBranch)
+}
+
+func getPullRequest(ctx context.Context, repo RepoName, number int) (PullRequest, error) {
+	provider, err := RepoProviderFor(repo.String())
+	if err != nil {
+		return PullRequest{}, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, providerLookupTimeout)
+	defer cancel()
+	return provider.GetPullRequest(ctx, repo.Path, number)
+}
+
+// doGetReview gets the head of a pull or merge request into a branch named
+// after it, cloning the repo if needed. The branch is checked out in the
+// repo, or in its own worktree with worktree. With update, an existing
+// branch is re-fetched and updated to the head wherever it's checked out.
+func (c *getCmdContext) doGetReview(ctx context.Context, gitUrl *url.URL, r reviewRef, update, worktree bool) error {
+	if !dirExists(c.WorkingDir) {
+		err := c.doClone(gitUrl.String(), "")
+		if err != nil {
+			return err
+		}
+	} else if !isGitRepo(c.WorkingDir) {
+		return fmt.Errorf("'%s' already exists but is not a git repository", c.WorkingDir)
+	} else if err := c.lockedError(); err != nil {
+		return err
+	}
+
+	branch := r.Branch()
+	exists := c.branchExists(branch)
+	if exists && !update {
+		return nil
+	}
+	err := c.fixRemoteConfig(gitUrl)
+	if err != nil {
+		return fmt.Errorf("error fixing remote config: %w", err)
+	}
+	if exists {
+		remote, err := c.doExec(fmt.Sprintf(`git config --get %s`, shellQuote("branch."+branch+".remote")))
+		if err != nil {
+			return fmt.Errorf("branch '%s' in '%s' has no upstream: %w", branch, c.WorkingDir, err)
+		}
+		err = c.echoEvalf(`git fetch%s%s %s`, c.progressFlag(), c.pruneFlag(), shellQuote(remote))
+		if err != nil {
+			return fmt.Errorf("error fetching %s: %w", remote, err)
+		}
+	} else {
+		err = c.createReviewBranch(ctx, gitUrl, r)
+		if err != nil {
+			return err
+		}
+	}
+
+	checkedOut, found, err := worktreeWithBranch(c.WorkingDir, branch)
+	if err != nil {
+		return err
+	}
+	if !found && worktree {
+		dir := getWorktreeDir(c.WorkingDir, branch)
+		err = c.echoEvalf(`git worktree add %s %s`, shellQuote(dir), shellQuote(branch))
+		if err != nil {
+			return fmt.Errorf("error adding worktree for '%s': %w", branch, err)
+		}
+		wc := *c
+		wc.WorkingDir = dir
+		return wc.updateCheckoutExtras()
+	}
+	if !found {
+		return c.checkoutReviewBranch(branch, exists)
+	}
+
+	// already checked out, bring it up to date in place
+	wc := *c
+	wc.WorkingDir = checkedOut.Path
+	stashed, err := wc.stash()
+	if err != nil {
+		return fmt.Errorf("error stashing: %w", err)
+	}
+	err = wc.updateReviewBranch(branch)
+	if err != nil {
+		return err
+	}
+	if stashed {
+		err = wc.restoreReviewStash()
+		if err != nil {
+			return err
+		}
+	}
+	return wc.updateCheckoutExtras()
+}
+
+// checkoutReviewBranch switches the repo to the branch, stashing any
+// uncommitted changes
+func (c *getCmdContext) checkoutReviewBranch(branch string, existed bool) error {
+	stashed, err := c.stash()
+	if err != nil {
+		return fmt.Errorf("error stashing: %w", err)
+	}
+	err = c.echoEvalf(`git checkout %s`, shellQuote(branch))
+	if err != nil {
+		return fmt.Errorf("error checking out branch '%s': %w", branch, err)
+	}
+	if existed {
+		err = c.updateReviewBranch(branch)
+		if err != nil {
+			return err
+		}
+	}
+	if stashed {
+		err = c.restoreReviewStash()
+		if err != nil {
+			return err
+		}
+	}
+	return c.updateCheckoutExtras()
+}
+
+// restoreReviewStash re-applies the changes stashed before updating a review
+// branch with RestoreStash, otherwise says where they were left
+func (c *getCmdContext) restoreReviewStash() error {
+	if c.RestoreStash {
+		return c.restoreStash()
+	}
+	c.info(fmt.Sprintf("Left uncommitted changes in '%s' in the stash, use 'git stash pop' or --restore-stash to re-apply them", c.WorkingDir))
+	return nil
+}
+
+// updateReviewBranch brings the checked out review branch in line with the
+// review's head. Review branches mirror the head, which is routinely
+// force-pushed or rebased, so when it can't be fast-forwarded the branch is
+// reset to it, backing it up first if it has commits that were never on the head.
+func (c *getCmdContext) updateReviewBranch(branch string) error {
+	d, err := c.checkDivergence()
+	if err != nil {
+		return err
+	}
+	if d == nil {
+		err = c.echoEval(`git merge --ff-only @{u}`)
+		if err != nil {
+			return fmt.Errorf("error fast-forwarding branch '%s': %w", branch, err)
+		}
+		return nil
+	}
+
+	if d.LocalCommits > 0 {
+		backup := c.backupBranchName(time.Now())
+		err = c.echoEvalf(`git branch %s`, shellQuote(backup))
+		if err != nil {
+			return fmt.Errorf("error backing up review branch '%s': %w", branch, err)
+		}
+		c.info(fmt.Sprintf("Backed up review branch '%s' in '%s' to '%s' before resetting it (%s)", branch, c.WorkingDir, backup, d))
+	}
+	err = c.echoEval(`git reset --hard @{u}`)
+	if err != nil {
+		return fmt.Errorf("error resetting review branch '%s': %w", branch, err)
+	}
+	return nil
+}
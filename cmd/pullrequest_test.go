@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseReviewRef(t *testing.T) {
+	tests := map[string]struct {
+		ok        bool
+		branch    string
+		remoteRef string
+	}{
+		"pr/123":  {true, "pr/123", "refs/pull/123/head"},
+		"mr/45":   {true, "mr/45", "refs/merge-requests/45/head"},
+		"pr/0":    {false, "", ""},
+		"pr/12a":  {false, "", ""},
+		"main":    {false, "", ""},
+		"pr":      {false, "", ""},
+		"x/pr/12": {false, "", ""},
+	}
+	for ref, expected := range tests {
+		r, ok := parseReviewRef(ref)
+		if ok != expected.ok {
+			t.Errorf("%s: expected ok %v, got %v", ref, expected.ok, ok)
+			continue
+		}
+		if ok && (r.Branch() != expected.branch || r.RemoteRef() != expected.remoteRef) {
+			t.Errorf("%s: expected %s and %s, got %s and %s", ref, expected.branch, expected.remoteRef, r.Branch(), r.RemoteRef())
+		}
+	}
+}
+
+func TestDoGetReview(t *testing.T) {
+	remote, local := newTestRepoWithRemote(t)
+	t.Setenv("ORGIT_WORKSPACE", filepath.Dir(local))
+	other := filepath.Join(t.TempDir(), "other")
+	gitInTest(t, filepath.Dir(other), "clone", "--quiet", remote, other)
+
+	pushReview := func(ref, file string) {
+		writeTestFile(t, filepath.Join(other, file), file)
+		gitInTest(t, other, "add", file)
+		gitInTest(t, other, "commit", "--quiet", "-m", file)
+		gitInTest(t, other, "push", "--quiet", "--force", "origin", "HEAD:"+ref)
+	}
+	rev := func(dir, rev string) string {
+		out, err := doExecQuietWithOutput(dir, "git rev-parse "+rev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(out)
+	}
+
+	gitInTest(t, other, "checkout", "--quiet", "-b", "feature")
+	pushReview("refs/pull/7/head", "f1")
+
+	remoteUrl, _ := url.Parse(remote)
+	c := getCmdContext{
+		Stdout:      io.Discard,
+		Stderr:      io.Discard,
+		CmdEchoFunc: func(cmd, dir string) {},
+		InfoFunc:    func(msg string) {},
+		WorkingDir:  local,
+	}
+	pr7, _ := parseReviewRef("pr/7")
+	if err := c.doGetReview(context.Background(), remoteUrl, pr7, false, false); err != nil {
+		t.Fatal(err)
+	}
+	if actual := rev(local, "--abbrev-ref HEAD"); actual != "pr/7" {
+		t.Errorf("expected pr/7 to be checked out, got %s", actual)
+	}
+	if actual := rev(local, "--symbolic-full-name @{u}"); actual != "refs/remotes/origin/pull/7/head" {
+		t.Errorf("expected pr/7 to track the pull request, got %s", actual)
+	}
+
+	// only re-fetched with update
+	pushReview("refs/pull/7/head", "f2")
+	if err := c.doGetReview(context.Background(), remoteUrl, pr7, false, false); err != nil {
+		t.Fatal(err)
+	}
+	if rev(local, "HEAD") == rev(other, "HEAD") {
+		t.Error("expected pr/7 not to be updated without update")
+	}
+	if err := c.doGetReview(context.Background(), remoteUrl, pr7, true, false); err != nil {
+		t.Fatal(err)
+	}
+	if rev(local, "HEAD") != rev(other, "HEAD") {
+		t.Error("expected pr/7 to be fast-forwarded with update")
+	}
+
+	// a force-pushed head replaces the branch
+	gitInTest(t, other, "reset", "--quiet", "--hard", "HEAD~1")
+	pushReview("refs/pull/7/head", "f2-rebased")
+	if err := c.doGetReview(context.Background(), remoteUrl, pr7, true, false); err != nil {
+		t.Fatal(err)
+	}
+	if rev(local, "HEAD") != rev(other, "HEAD") {
+		t.Error("expected pr/7 to be reset to the force-pushed head")
+	}
+	backups := func() string {
+		out, _ := doExecQuietWithOutput(local, "git for-each-ref --format='%(objectname)' refs/heads/"+backupBranchPrefix)
+		return strings.TrimSpace(out)
+	}
+	if actual := backups(); actual != "" {
+		t.Errorf("expected no backup without local commits, got %s", actual)
+	}
+
+	// local commits are backed up before a force-pushed head replaces them
+	gitInTest(t, local, "commit", "--quiet", "--allow-empty", "-m", "local")
+	localCommit := rev(local, "HEAD")
+	gitInTest(t, other, "reset", "--quiet", "--hard", "HEAD~1")
+	pushReview("refs/pull/7/head", "f2-rebased-again")
+	if err := c.doGetReview(context.Background(), remoteUrl, pr7, true, false); err != nil {
+		t.Fatal(err)
+	}
+	if rev(local, "HEAD") != rev(other, "HEAD") {
+		t.Error("expected pr/7 to be reset to the force-pushed head")
+	}
+	if actual := backups(); actual != localCommit {
+		t.Errorf("expected the local commit to be backed up, got %q", actual)
+	}
+	gitInTest(t, local, "fetch", "--quiet", "origin")
+
+	// uncommitted changes are stashed to switch to the review branch, and
+	// only re-applied with RestoreStash
+	var infos []string
+	c.InfoFunc = func(msg string) { infos = append(infos, msg) }
+	for _, restore := range []bool{false, true} {
+		gitInTest(t, local, "checkout", "--quiet", "-")
+		writeTestFile(t, filepath.Join(local, "wip"), "wip")
+		infos = nil
+		c.RestoreStash = restore
+		if err := c.doGetReview(context.Background(), remoteUrl, pr7, true, false); err != nil {
+			t.Fatal(err)
+		}
+		if actual := rev(local, "--abbrev-ref HEAD"); actual != "pr/7" {
+			t.Errorf("expected pr/7 to be checked out, got %s", actual)
+		}
+		if fileExists(filepath.Join(local, "wip")) != restore {
+			t.Errorf("expected the stash to be restored only with RestoreStash %v", restore)
+		}
+		left := slices.ContainsFunc(infos, func(msg string) bool { return strings.Contains(msg, "in the stash") })
+		if left == restore {
+			t.Errorf("expected to be told the changes were left in the stash only without RestoreStash %v, got %v", restore, infos)
+		}
+		gitInTest(t, local, "stash", "clear")
+		if restore {
+			gitInTest(t, local, "clean", "--quiet", "--force")
+		}
+	}
+	c.InfoFunc = func(msg string) {}
+	c.RestoreStash = false
+
+	// a worktree leaves the repo's checkout alone
+	pushReview("refs/pull/8/head", "f3")
+	pr8, _ := parseReviewRef("pr/8")
+	if err := c.doGetReview(context.Background(), remoteUrl, pr8, false, true); err != nil {
+		t.Fatal(err)
+	}
+	worktree := getWorktreeDir(local, "pr/8")
+	if !fileExists(filepath.Join(worktree, ".git")) {
+		t.Fatalf("expected worktree at %s", worktree)
+	}
+	if actual := rev(local, "--abbrev-ref HEAD"); actual != "pr/7" {
+		t.Errorf("expected the repo to stay on pr/7, got %s", actual)
+	}
+	pushReview("refs/pull/8/head", "f4")
+	if err := c.doGetReview(context.Background(), remoteUrl, pr8, true, false); err != nil {
+		t.Fatal(err)
+	}
+	if rev(worktree, "HEAD") != rev(other, "HEAD") {
+		t.Error("expected pr/8 to be fast-forwarded in its worktree")
+	}
+}
+
+func TestCreateForkReviewBranch(t *testing.T) {
+	remote, local := newTestRepoWithRemote(t)
+	fork := filepath.Join(t.TempDir(), "fork.git")
+	gitInTest(t, filepath.Dir(fork), "clone", "--quiet", "--bare", remote, fork)
+	gitInTest(t, local, "commit", "--quiet", "--allow-empty", "-m", "fix")
+	gitInTest(t, local, "push", "--quiet", fork, "HEAD:refs/heads/fix")
+	gitInTest(t, local, "reset", "--quiet", "--hard", "HEAD~1")
+
+	c := getCmdContext{
+		Stdout:      io.Discard,
+		Stderr:      io.Discard,
+		CmdEchoFunc: func(cmd, dir string) {},
+		WorkingDir:  local,
+	}
+	pr := PullRequest{
+		Number:       9,
+		SourceRepo:   RepoName{Host: "github.com", Path: "alice/repo"},
+		SourceUrl:    fork,
+		SourceBranch: "fix",
+	}
+	pr9, _ := parseReviewRef("pr/9")
+	if err := c.createForkReviewBranch(pr9, pr); err != nil {
+		t.Fatal(err)
+	}
+	out, err := doExecQuietWithOutput(local, "git rev-parse --symbolic-full-name pr/9@{u}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := strings.TrimSpace(out); actual != "refs/remotes/alice/fix" {
+		t.Errorf("expected pr/9 to track the fork's branch, got %s", actual)
+	}
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const remoteCacheDir = "remotes"
+
+// remoteRepoListing is the list of repos of a sync target, cached by
+// 'orgit sync' for shell completion and 'orgit ui'
+type remoteRepoListing struct {
+	Target  string             `json:"target"`
+	Updated time.Time          `json:"updated"`
+	Repos   []cachedRemoteRepo `json:"repos"`
+}
+
+type cachedRemoteRepo struct {
+	Repo          string `json:"repo"`
+	CloneUrl      string `json:"clone_url"`
+	Archived      bool   `json:"archived,omitempty"`
+	DefaultBranch string `json:"default_branch,omitempty"`
+	Description   string `json:"description,omitempty"`
+}
+
+func getRemoteCacheDir() string {
+	return filepath.Join(getStateDir(), remoteCacheDir)
+}
+
+// writeRemoteRepoListing replaces the cached listing of target, e.g.
+// .orgit/remotes/github.com/my-org.json
+func writeRemoteRepoListing(target RepoName, repos []RemoteRepo, updated time.Time) error {
+	listing := remoteRepoListing{Target: target.String(), Updated: updated.UTC(), Repos: []cachedRemoteRepo{}}
+	for _, r := range repos {
+		listing.Repos = append(listing.Repos, cachedRemoteRepo{
+			Repo:          r.RepoName.String(),
+			CloneUrl:      r.CloneUrl,
+			Archived:      r.IsArchived,
+			DefaultBranch: r.DefaultBranch,
+			Description:   r.Description,
+		})
+	}
+	slices.SortFunc(listing.Repos, func(a, b cachedRemoteRepo) int {
+		return strings.Compare(a.Repo, b.Repo)
+	})
+
+	file := filepath.Join(getRemoteCacheDir(), filepath.FromSlash(target.String())+".json")
+	err := os.MkdirAll(filepath.Dir(file), 0755)
+	if err != nil {
+		return fmt.Errorf("couldn't create remote cache dir: %w", err)
+	}
+	data, err := json.Marshal(listing)
+	if err != nil {
+		return fmt.Errorf("couldn't encode remote listing of '%s': %w", target, err)
+	}
+
+	// written to a temporary file first so readers never see a partial listing
+	tmp := file + ".tmp"
+	err = os.WriteFile(tmp, data, 0644)
+	if err != nil {
+		return fmt.Errorf("couldn't write remote listing of '%s': %w", target, err)
+	}
+	return os.Rename(tmp, file)
+}
+
+// readCachedRemoteRepos returns the repos of every cached listing, sorted by
+// name. Where listings overlap, e.g. for a group and its subgroup, the most
+// recent listing wins. Unreadable listings are skipped.
+func readCachedRemoteRepos() []RemoteRepo {
+	listings := []remoteRepoListing{}
+	_ = filepath.WalkDir(getRemoteCacheDir(), func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".json" {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		var l remoteRepoListing
+		if json.Unmarshal(data, &l) == nil {
+			listings = append(listings, l)
+		}
+		return nil
+	})
+	slices.SortFunc(listings, func(a, b remoteRepoListing) int {
+		return b.Updated.Compare(a.Updated)
+	})
+
+	repos := []RemoteRepo{}
+	seen := map[string]bool{}
+	for _, l := range listings {
+		for _, r := range l.Repos {
+			name, err := ParseRepoName(r.Repo)
+			if err != nil || seen[r.Repo] {
+				continue
+			}
+			seen[r.Repo] = true
+			repos = append(repos, RemoteRepo{
+				RepoName:      name,
+				CloneUrl:      r.CloneUrl,
+				IsArchived:    r.Archived,
+				DefaultBranch: r.DefaultBranch,
+				Description:   r.Description,
+			})
+		}
+	}
+	slices.SortFunc(repos, func(a, b RemoteRepo) int {
+		return strings.Compare(a.RepoName.String(), b.RepoName.String())
+	})
+	return repos
+}
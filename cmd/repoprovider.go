@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"net/url"
 	"os"
 	"os/user"
 	"path/filepath"
@@ -32,6 +34,9 @@ func init() {
 	glabHosts = slices.Compact(glabHosts)
 
 	for _, host := range glabHosts {
+		if host == "" {
+			continue
+		}
 		KnownGitProviders = append(KnownGitProviders, NewGitlabRepoProvider(host))
 	}
 }
@@ -41,6 +46,17 @@ type RepoProvider interface {
 	NormaliseGitUrl(s string) string
 	ListRepos(ctx context.Context, org string, includeArchived bool, remoteRepoChan chan RemoteRepo) error
 	GetRepo(ctx context.Context, repoName string) (RemoteRepo, error)
+	GetPullRequest(ctx context.Context, repoName string, number int) (PullRequest, error)
+	SearchRepos(ctx context.Context, query string, limit int) ([]RemoteRepo, error)
+}
+
+// PullRequest is where the changes of a GitHub pull request or GitLab merge
+// request come from, which may be a fork
+type PullRequest struct {
+	Number       int
+	SourceRepo   RepoName
+	SourceUrl    string
+	SourceBranch string
 }
 
 func RepoProviderFor(s string) (RepoProvider, error) {
@@ -68,11 +84,12 @@ func (p genericRepoProvider) NormaliseGitUrl(s string) string {
 
 type GithubRepoProvider struct {
 	genericRepoProvider
+	apiBaseUrl string // defaults to https://api.github.com/
 }
 
 func NewGithubRepoProvider() GithubRepoProvider {
 	return GithubRepoProvider{
-		genericRepoProvider{
+		genericRepoProvider: genericRepoProvider{
 			prefix:       "github.com/",
 			appendPrefix: "https://",
 			appendSuffix: ".git",
@@ -83,11 +100,15 @@ func NewGithubRepoProvider() GithubRepoProvider {
 func (gh GithubRepoProvider) getClient(ctx context.Context) *github.Client {
 	githubToken := getNetrcPasswordForMachine("api.github.com")
 
+	client := github.NewClient(nil)
 	if githubToken != "" {
-		return github.NewTokenClient(ctx, githubToken)
+		client = github.NewTokenClient(ctx, githubToken)
+	}
+	if gh.apiBaseUrl != "" {
+		client.BaseURL, _ = url.Parse(gh.apiBaseUrl)
 	}
 
-	return github.NewClient(nil)
+	return client
 }
 
 func (gh GithubRepoProvider) GetRepo(ctx context.Context, repoUrl string) (RemoteRepo, error) {
@@ -100,19 +121,80 @@ func (gh GithubRepoProvider) GetRepo(ctx context.Context, repoUrl string) (Remot
 	owner := repoParts[len(repoParts)-2]
 	repo := repoParts[len(repoParts)-1]
 
-	r, _, err := client.Repositories.Get(ctx, owner, repo)
+	// renamed and transferred repos are redirected to their new name
+	r, resp, err := client.Repositories.Get(ctx, owner, repo)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return RemoteRepo{}, ErrRepoNotFound
+	}
 	if err != nil {
 		return RemoteRepo{}, fmt.Errorf("error getting repo %s/%s: %w", owner, repo, err)
 	}
 
+	return githubRemoteRepo(r), nil
+}
+
+// githubRemoteRepo names the repo after its web URL, as the API URL has a
+// different host and path
+func githubRemoteRepo(r *github.Repository) RemoteRepo {
 	return RemoteRepo{
-		RepoName:      MustParseRepoName(r.GetURL()),
+		RepoName:      MustParseRepoName(r.GetHTMLURL()),
 		CloneUrl:      r.GetCloneURL(),
 		IsArchived:    r.GetArchived(),
 		DefaultBranch: r.GetDefaultBranch(),
+		Description:   r.GetDescription(),
+	}
+}
+
+func (gh GithubRepoProvider) GetPullRequest(ctx context.Context, repoUrl string, number int) (PullRequest, error) {
+	client := gh.getClient(ctx)
+	repoParts := strings.Split(repoUrl, "/")
+	if len(repoParts) < 2 {
+		return PullRequest{}, fmt.Errorf("invalid github repo url '%s'", repoUrl)
+	}
+
+	owner := repoParts[len(repoParts)-2]
+	repo := repoParts[len(repoParts)-1]
+
+	pr, _, err := client.PullRequests.Get(ctx, owner, repo, number)
+	if err != nil {
+		return PullRequest{}, fmt.Errorf("error getting pull request %s/%s#%d: %w", owner, repo, number, err)
+	}
+	source := pr.GetHead().GetRepo()
+	if source == nil {
+		return PullRequest{}, fmt.Errorf("the source repo of pull request %s/%s#%d has been deleted", owner, repo, number)
+	}
+
+	return PullRequest{
+		Number:       number,
+		SourceRepo:   MustParseRepoName(source.GetCloneURL()),
+		SourceUrl:    source.GetCloneURL(),
+		SourceBranch: pr.GetHead().GetRef(),
 	}, nil
 }
 
+// SearchRepos searches the names of repos, or of the repos of an owner when
+// query is OWNER/NAME, in GitHub's order of relevance
+func (gh GithubRepoProvider) SearchRepos(ctx context.Context, query string, limit int) ([]RemoteRepo, error) {
+	client := gh.getClient(ctx)
+	q := query + " in:name"
+	if owner, name, ok := strings.Cut(query, "/"); ok {
+		q = fmt.Sprintf("%s in:name user:%s", name, owner)
+	}
+
+	result, _, err := client.Search.Repositories(ctx, q, &github.SearchOptions{
+		ListOptions: github.ListOptions{PerPage: limit},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error searching repos for '%s': %w", query, err)
+	}
+
+	repos := []RemoteRepo{}
+	for _, r := range result.Repositories {
+		repos = append(repos, githubRemoteRepo(r))
+	}
+	return repos, nil
+}
+
 func (gh GithubRepoProvider) ListRepos(ctx context.Context, org string, includeArchived bool, remoteRepoChan chan RemoteRepo) error {
 	client := gh.getClient(ctx)
 	_, _, err := client.Organizations.Get(ctx, org)
@@ -144,13 +226,7 @@ func (gh GithubRepoProvider) ListReposByUser(ctx context.Context, org string, in
 				if repo.GetArchived() && !includeArchived {
 					continue
 				}
-				r := RemoteRepo{
-					RepoName:      MustParseRepoName(repo.GetURL()),
-					CloneUrl:      repo.GetCloneURL(),
-					IsArchived:    repo.GetArchived(),
-					DefaultBranch: repo.GetDefaultBranch(),
-				}
-				remoteRepoChan <- r
+				remoteRepoChan <- githubRemoteRepo(repo)
 			}
 
 			if resp.NextPage == 0 {
@@ -182,13 +258,7 @@ func (gh GithubRepoProvider) ListReposByOrg(ctx context.Context, org string, inc
 				if repo.GetArchived() && !includeArchived {
 					continue
 				}
-				r := RemoteRepo{
-					RepoName:      MustParseRepoName(repo.GetURL()),
-					CloneUrl:      repo.GetCloneURL(),
-					IsArchived:    repo.GetArchived(),
-					DefaultBranch: repo.GetDefaultBranch(),
-				}
-				remoteRepoChan <- r
+				remoteRepoChan <- githubRemoteRepo(repo)
 			}
 
 			if resp.NextPage == 0 {
@@ -215,7 +285,8 @@ func getNetrcPasswordForMachine(machine string) string {
 
 type GitlabRepoProvider struct {
 	genericRepoProvider
-	host string
+	host       string
+	apiBaseUrl string
 }
 
 func NewGitlabRepoProvider(host string) GitlabRepoProvider {
@@ -225,13 +296,16 @@ func NewGitlabRepoProvider(host string) GitlabRepoProvider {
 			appendPrefix: "https://",
 			appendSuffix: ".git",
 		},
-		host: host,
+		host:       host,
+		apiBaseUrl: "https://" + host,
 	}
 }
 
 func (gl GitlabRepoProvider) getClient() (*gitlab.Client, error) {
 	gitlabToken := getNetrcPasswordForMachine(gl.host)
-	options := []gitlab.ClientOptionFunc{}
+	options := []gitlab.ClientOptionFunc{
+		gitlab.WithBaseURL(gl.apiBaseUrl),
+	}
 
 	if logLevelFlag == "debug" {
 		options = append(options, gitlab.WithCustomLogger(log.New(os.Stderr, "", log.LstdFlags)))
@@ -295,6 +369,7 @@ func (gl GitlabRepoProvider) ListReposByOrg(ctx context.Context, client *gitlab.
 					CloneUrl:      p.HTTPURLToRepo,
 					IsArchived:    p.Archived,
 					DefaultBranch: p.DefaultBranch,
+					Description:   p.Description,
 				}
 				remoteRepoChan <- r
 			}
@@ -362,6 +437,7 @@ func (gl GitlabRepoProvider) ListReposByUser(ctx context.Context, client *gitlab
 					CloneUrl:      p.HTTPURLToRepo,
 					IsArchived:    p.Archived,
 					DefaultBranch: p.DefaultBranch,
+					Description:   p.Description,
 				}
 
 				remoteRepoChan <- r
@@ -402,5 +478,63 @@ func (gl GitlabRepoProvider) GetRepo(ctx context.Context, repoName string) (Remo
 		CloneUrl:      p.HTTPURLToRepo,
 		IsArchived:    p.Archived,
 		DefaultBranch: p.DefaultBranch,
+		Description:   p.Description,
+	}, nil
+}
+
+// SearchRepos searches the names and paths of the projects visible to the
+// user, most recently active first
+func (gl GitlabRepoProvider) SearchRepos(ctx context.Context, query string, limit int) ([]RemoteRepo, error) {
+	client, err := gl.getClient()
+	if err != nil {
+		return nil, fmt.Errorf("error creating gitlab client: %w", err)
+	}
+
+	ps, _, err := client.Projects.ListProjects(&gitlab.ListProjectsOptions{
+		ListOptions:      gitlab.ListOptions{PerPage: limit},
+		Search:           gitlab.Ptr(query),
+		SearchNamespaces: gitlab.Ptr(true),
+		OrderBy:          gitlab.Ptr("last_activity_at"),
+	}, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("error searching projects for '%s': %w", query, err)
+	}
+
+	repos := []RemoteRepo{}
+	for _, p := range ps {
+		if p.RepositoryAccessLevel == "disabled" {
+			continue
+		}
+		repos = append(repos, RemoteRepo{
+			RepoName:      MustParseRepoName(p.WebURL),
+			CloneUrl:      p.HTTPURLToRepo,
+			IsArchived:    p.Archived,
+			DefaultBranch: p.DefaultBranch,
+			Description:   p.Description,
+		})
+	}
+	return repos, nil
+}
+
+func (gl GitlabRepoProvider) GetPullRequest(ctx context.Context, repoName string, number int) (PullRequest, error) {
+	client, err := gl.getClient()
+	if err != nil {
+		return PullRequest{}, fmt.Errorf("error creating gitlab client: %w", err)
+	}
+
+	mr, _, err := client.MergeRequests.GetMergeRequest(repoName, number, nil, gitlab.WithContext(ctx))
+	if err != nil {
+		return PullRequest{}, fmt.Errorf("error getting merge request %s!%d: %w", repoName, number, err)
+	}
+	source, _, err := client.Projects.GetProject(mr.SourceProjectID, nil, gitlab.WithContext(ctx))
+	if err != nil {
+		return PullRequest{}, fmt.Errorf("error getting the source project of merge request %s!%d: %w", repoName, number, err)
+	}
+
+	return PullRequest{
+		Number:       number,
+		SourceRepo:   MustParseRepoName(source.WebURL),
+		SourceUrl:    source.HTTPURLToRepo,
+		SourceBranch: mr.SourceBranch,
 	}, nil
 }
@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sourcegraph/conc/pool"
+	"github.com/spf13/cobra"
+)
+
+const defaultSearchLimit = 20
+
+// searchResult is a repo matching the query of 'orgit search'
+type searchResult struct {
+	Repo        string `json:"repo"`
+	Description string `json:"description,omitempty"`
+	Archived    bool   `json:"archived"`
+	Cloned      bool   `json:"cloned"`
+}
+
+// searchProvidersFor returns the providers of hosts, or all known providers
+// if no hosts are given
+func searchProvidersFor(hosts []string) ([]RepoProvider, error) {
+	if len(hosts) == 0 {
+		return KnownGitProviders, nil
+	}
+	providers := []RepoProvider{}
+	for _, host := range hosts {
+		provider, err := RepoProviderFor(strings.TrimSuffix(host, "/") + "/")
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, provider)
+	}
+	return providers, nil
+}
+
+// searchRemoteRepos searches each provider concurrently, keeping the order of
+// the providers and of each provider's results. A provider that can't be
+// searched, e.g. because of rate limiting, doesn't stop the others.
+func searchRemoteRepos(ctx context.Context, providers []RepoProvider, query string, limit int) ([]RemoteRepo, []error) {
+	results := make([][]RemoteRepo, len(providers))
+	errs := make([]error, len(providers))
+	p := pool.New().WithMaxGoroutines(SyncWorkerPoolSize).WithContext(ctx)
+	for i, provider := range providers {
+		p.Go(func(ctx context.Context) error {
+			results[i], errs[i] = provider.SearchRepos(ctx, query, limit)
+			return nil
+		})
+	}
+	_ = p.Wait()
+
+	repos := []RemoteRepo{}
+	for _, r := range results {
+		repos = append(repos, r...)
+	}
+	return repos, slices.DeleteFunc(errs, func(err error) bool { return err == nil })
+}
+
+// searchLocalRepos returns the repos in the workspace whose path contains
+// query, ignoring case, on one of hosts if any are given
+func searchLocalRepos(query string, hosts []string) []RepoName {
+	baseDir := getWorkspaceDir()
+	if !dirExists(baseDir) {
+		return nil
+	}
+	query = strings.ToLower(query)
+
+	repos := []RepoName{}
+	forEachGitDirIn(baseDir, func(relativeDir string) {
+		r, err := ParseRepoName(filepath.ToSlash(relativeDir))
+		if err != nil {
+			return
+		}
+		if len(hosts) > 0 && !slices.Contains(hosts, r.Host) {
+			return
+		}
+		if strings.Contains(strings.ToLower(r.String()), query) {
+			repos = append(repos, r)
+		}
+	})
+	slices.SortFunc(repos, func(a, b RepoName) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	return repos
+}
+
+// searchRepos finds the repos matching query with the providers' search APIs,
+// followed by the matching repos in the workspace that weren't found remotely
+func searchRepos(ctx context.Context, query string, hosts []string, limit int) ([]searchResult, []error) {
+	providers, err := searchProvidersFor(hosts)
+	if err != nil {
+		return nil, []error{err}
+	}
+	remote, errs := searchRemoteRepos(ctx, providers, query, limit)
+
+	results := []searchResult{}
+	seen := map[RepoName]bool{}
+	for _, r := range remote {
+		if seen[r.RepoName] {
+			continue
+		}
+		seen[r.RepoName] = true
+		results = append(results, searchResult{
+			Repo:        r.RepoName.String(),
+			Description: cleanString(r.Description),
+			Archived:    r.IsArchived,
+			Cloned:      dirExists(r.RepoName.LocalPathAbsolute()),
+		})
+	}
+	for _, r := range searchLocalRepos(query, hosts) {
+		if !seen[r] {
+			seen[r] = true
+			results = append(results, searchResult{Repo: r.String(), Cloned: true})
+		}
+	}
+	return results, errs
+}
+
+func yesOrNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func printSearchResultsTable(results []searchResult) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REPO\tCLONED\tARCHIVED\tDESCRIPTION")
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Repo, yesOrNo(r.Cloned), yesOrNo(r.Archived), r.Description)
+	}
+	w.Flush()
+}
+
+// readArgsFromStdin reads one argument per line, taking the first field of
+// each line so the output of 'orgit search' can be piped in as is
+func readArgsFromStdin(r io.Reader) ([]string, error) {
+	args := []string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] == "REPO" {
+			continue
+		}
+		args = append(args, fields[0])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading stdin: %w", err)
+	}
+	return args, nil
+}
+
+func init() {
+	var hosts []string
+	var limit int
+	var jsonFlag bool
+	var namesFlag bool
+	var notCloned bool
+
+	var cmdSearch = &cobra.Command{
+		Use:   "search [flags] QUERY",
+		Short: "Search for repositories by name",
+		Long: `Search for repositories whose name matches QUERY with the GitHub and GitLab search
+APIs, and in the workspace. A QUERY of OWNER/NAME searches the repos of an owner
+on GitHub. Each match shows whether it's archived and whether it's already
+cloned in the workspace. Repos only found in the workspace come last.
+
+Use --names to print only the repo names, which 'orgit get -' reads from stdin,
+e.g. to pick the repos to get with fzf:
+
+  orgit search --names --not-cloned api | fzf -m | orgit get -
+
+Arguments:
+  QUERY  A fragment of the repo name, e.g. "service" or "my-org/service"
+`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			results, errs := searchRepos(cmd.Context(), args[0], hosts, limit)
+			for _, err := range errs {
+				cmd.PrintErrln("Warning:", err)
+			}
+			if notCloned {
+				results = slices.DeleteFunc(results, func(r searchResult) bool { return r.Cloned })
+			}
+
+			switch {
+			case jsonFlag:
+				err := printJson(results)
+				if err != nil {
+					cmd.PrintErrln(err)
+					os.Exit(1)
+				}
+			case namesFlag:
+				for _, r := range results {
+					fmt.Println(r.Repo)
+				}
+			case len(results) == 0:
+				cmd.PrintErrf("No repos match '%s'\n", args[0])
+			default:
+				printSearchResultsTable(results)
+			}
+			if len(results) == 0 && len(errs) > 0 {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmdSearch.Flags().StringSliceVar(&hosts, "host", nil, "Only search these hosts, e.g. github.com. Defaults to github.com and the GitLab hosts")
+	cmdSearch.Flags().IntVar(&limit, "limit", defaultSearchLimit, "The maximum number of results from each host")
+	cmdSearch.Flags().BoolVar(&jsonFlag, "json", false, "Print the results as JSON")
+	cmdSearch.Flags().BoolVar(&namesFlag, "names", false, "Print only the names of the repos, one per line")
+	cmdSearch.Flags().BoolVar(&notCloned, "not-cloned", false, "Leave out repos that are already cloned")
+	rootCmd.AddCommand(cmdSearch)
+}
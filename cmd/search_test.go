@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func newTestApiServer(t *testing.T, path, body string, gotQuery *string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			http.NotFound(w, r)
+			return
+		}
+		*gotQuery = r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGithubSearchRepos(t *testing.T) {
+	var query string
+	server := newTestApiServer(t, "/search/repositories", `{
+		"total_count": 2,
+		"items": [
+			{"html_url": "https://github.com/org/api-service", "clone_url": "https://github.com/org/api-service.git", "description": "The API", "default_branch": "main"},
+			{"html_url": "https://github.com/org/old-api", "clone_url": "https://github.com/org/old-api.git", "archived": true, "default_branch": "master"}
+		]
+	}`, &query)
+
+	gh := NewGithubRepoProvider()
+	gh.apiBaseUrl = server.URL + "/"
+	repos, err := gh.SearchRepos(context.Background(), "org/api", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(query, "q=api+in%3Aname+user%3Aorg") || !strings.Contains(query, "per_page=10") {
+		t.Errorf("unexpected query '%s'", query)
+	}
+	want := []RemoteRepo{
+		{RepoName: MustParseRepoName("github.com/org/api-service"), CloneUrl: "https://github.com/org/api-service.git", DefaultBranch: "main", Description: "The API"},
+		{RepoName: MustParseRepoName("github.com/org/old-api"), CloneUrl: "https://github.com/org/old-api.git", DefaultBranch: "master", IsArchived: true},
+	}
+	if !slices.Equal(repos, want) {
+		t.Errorf("got %+v, want %+v", repos, want)
+	}
+}
+
+func TestGithubSearchReposError(t *testing.T) {
+	var query string
+	server := newTestApiServer(t, "/nothing", "", &query)
+
+	gh := NewGithubRepoProvider()
+	gh.apiBaseUrl = server.URL + "/"
+	_, err := gh.SearchRepos(context.Background(), "api", 10)
+	if err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestGitlabSearchRepos(t *testing.T) {
+	var query string
+	server := newTestApiServer(t, "/api/v4/projects", `[
+		{"web_url": "https://gitlab.example.com/group/api", "http_url_to_repo": "https://gitlab.example.com/group/api.git", "description": "The API", "default_branch": "main"},
+		{"web_url": "https://gitlab.example.com/group/no-repo", "repository_access_level": "disabled"}
+	]`, &query)
+
+	gl := NewGitlabRepoProvider("gitlab.example.com")
+	gl.apiBaseUrl = server.URL
+	repos, err := gl.SearchRepos(context.Background(), "group/api", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, q := range []string{"search=group%2Fapi", "search_namespaces=true", "per_page=5"} {
+		if !strings.Contains(query, q) {
+			t.Errorf("expected '%s' in query '%s'", q, query)
+		}
+	}
+	want := []RemoteRepo{
+		{RepoName: MustParseRepoName("gitlab.example.com/group/api"), CloneUrl: "https://gitlab.example.com/group/api.git", DefaultBranch: "main", Description: "The API"},
+	}
+	if !slices.Equal(repos, want) {
+		t.Errorf("got %+v, want %+v", repos, want)
+	}
+}
+
+func TestSearchRepos(t *testing.T) {
+	workspace := t.TempDir()
+	t.Setenv("ORGIT_WORKSPACE", workspace)
+	for _, dir := range []string{"example.org/org/api", "example.org/org/local-api", "example.com/other/api", "example.org/org/web"} {
+		err := os.MkdirAll(filepath.Join(workspace, dir, ".git"), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	newTestRepoProvider(t, "example.org", map[string]RemoteRepo{
+		"org/api":     {RepoName: MustParseRepoName("example.org/org/api"), Description: "The\tAPI"},
+		"org/old-api": {RepoName: MustParseRepoName("example.org/org/old-api"), IsArchived: true},
+		"org/web":     {RepoName: MustParseRepoName("example.org/org/web")},
+	})
+
+	results, errs := searchRepos(context.Background(), "API", []string{"example.org"}, 10)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	want := []searchResult{
+		{Repo: "example.org/org/api", Description: "The API", Cloned: true},
+		{Repo: "example.org/org/old-api", Archived: true},
+		{Repo: "example.org/org/local-api", Cloned: true},
+	}
+	if !slices.Equal(results, want) {
+		t.Errorf("got %+v, want %+v", results, want)
+	}
+
+	_, errs = searchRepos(context.Background(), "api", []string{"unknown.example"}, 10)
+	if len(errs) != 1 {
+		t.Errorf("expected an error for a host without a provider, got %v", errs)
+	}
+}
+
+func TestReadArgsFromStdin(t *testing.T) {
+	in := "REPO  CLONED  ARCHIVED  DESCRIPTION\ngithub.com/org/a  no  no  The A\n\n  github.com/org/b\n"
+	args, err := readArgsFromStdin(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"github.com/org/a", "github.com/org/b"}
+	if !slices.Equal(args, want) {
+		t.Errorf("got %v, want %v", args, want)
+	}
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const shellInitPosix = `# orgit shell integration, from 'orgit shell-init'
+
+# orgit wraps the orgit command so 'orgit cd QUERY' changes to the directory
+# of the best matching repo
+orgit() {
+  if [ "$1" = "cd" ]; then
+    shift
+    local dir
+    dir="$(command orgit cd "$@")" || return
+    if [ -d "$dir" ]; then
+      builtin cd -- "$dir"
+    elif [ -n "$dir" ]; then
+      printf '%s\n' "$dir"
+    fi
+  else
+    command orgit "$@"
+  fi
+}
+
+# orgit_prompt prints the current directory relative to the workspace, e.g.
+# for PS1, and nothing outside the workspace
+orgit_prompt() {
+  local workspace="${ORGIT_WORKSPACE:-}"
+  [ -n "$workspace" ] || workspace={{workspace}}
+  case "$PWD" in
+    "$workspace"/*) printf '%s' "${PWD#"$workspace"/}" ;;
+  esac
+}
+
+`
+
+const shellInitFish = `# orgit shell integration, from 'orgit shell-init fish'
+
+# orgit wraps the orgit command so 'orgit cd QUERY' changes to the directory
+# of the best matching repo
+function orgit
+    if test (count $argv) -gt 0; and test "$argv[1]" = cd
+        set -l out (command orgit cd $argv[2..-1]); or return
+        if test (count $out) -eq 1; and test -d "$out"
+            builtin cd -- $out
+        else if test (count $out) -gt 0
+            printf '%s\n' $out
+        end
+    else
+        command orgit $argv
+    end
+end
+
+# orgit_prompt prints the current directory relative to the workspace, e.g.
+# for fish_prompt, and nothing outside the workspace
+function orgit_prompt
+    set -l workspace $ORGIT_WORKSPACE
+    test -n "$workspace"; or set workspace {{workspace}}
+    string match -q -- "$workspace/*" "$PWD"; and string replace -- "$workspace/" '' "$PWD"
+end
+
+`
+
+// fishQuote quotes s for fish, where a backslash escapes a quote or another
+// backslash inside single quotes
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// writeShellInit writes the shell functions and completions for shell
+func writeShellInit(w io.Writer, shell string) error {
+	var err error
+	switch shell {
+	case "bash":
+		_, err = io.WriteString(w, strings.ReplaceAll(shellInitPosix, "{{workspace}}", shellQuote(getWorkspaceDir())))
+		if err == nil {
+			err = rootCmd.GenBashCompletionV2(w, true)
+		}
+	case "zsh":
+		_, err = io.WriteString(w, strings.ReplaceAll(shellInitPosix, "{{workspace}}", shellQuote(getWorkspaceDir())))
+		if err == nil {
+			err = rootCmd.GenZshCompletion(w)
+		}
+	case "fish":
+		_, err = io.WriteString(w, strings.ReplaceAll(shellInitFish, "{{workspace}}", fishQuote(getWorkspaceDir())))
+		if err == nil {
+			err = rootCmd.GenFishCompletion(w, true)
+		}
+	default:
+		return fmt.Errorf("unsupported shell '%s', expected bash, zsh or fish", shell)
+	}
+	if err != nil {
+		return fmt.Errorf("couldn't write shell integration: %w", err)
+	}
+	return nil
+}
+
+// findRepoDirs returns the directories of the repos in the workspace matching
+// query, best first. A repo's path or URL matches exactly, otherwise the names
+// of the repos in the workspace are matched fuzzily.
+func findRepoDirs(query string) []string {
+	baseDir := getWorkspaceDir()
+	if query == "" {
+		return []string{baseDir}
+	}
+	if dir := resolveWorkspacePath(baseDir, query); isGitRepo(dir) {
+		return []string{dir}
+	}
+
+	dirs := []string{}
+	for _, name := range fuzzyFilter(query, localRepoNames()) {
+		dirs = append(dirs, filepath.Join(baseDir, filepath.FromSlash(name)))
+	}
+	return dirs
+}
+
+func init() {
+	var listFlag bool
+
+	var cmdCd = &cobra.Command{
+		Use:   "cd [flags] [QUERY]",
+		Short: "Print the directory of the repository best matching QUERY",
+		Long: `Print the directory of the repository in the workspace best matching QUERY, or
+the workspace directory if QUERY is not specified.
+
+QUERY can be the path or URL of a repo, or a fuzzy match of its name, e.g.
+"orgapi" matches github.com/my-org/api. Matches of the repo's name are
+preferred over matches of its org.
+
+A program can't change the directory of the shell it runs in, so with the
+function from 'orgit shell-init' the shell changes to the directory instead.
+`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeFirstRepoArg(localRepoNames, false),
+		Run: func(cmd *cobra.Command, args []string) {
+			query := ""
+			if len(args) == 1 {
+				query = args[0]
+			}
+			dirs := findRepoDirs(query)
+			if len(dirs) == 0 {
+				cmd.PrintErrf("No repos in the workspace match '%s'\n", query)
+				os.Exit(1)
+			}
+			if !listFlag {
+				dirs = dirs[:1]
+			}
+			for _, dir := range dirs {
+				fmt.Println(dir)
+			}
+		},
+	}
+	cmdCd.Flags().BoolVar(&listFlag, "list", false, "Print the directories of all matching repos, best first")
+	rootCmd.AddCommand(cmdCd)
+
+	var cmdShellInit = &cobra.Command{
+		Use:   "shell-init bash|zsh|fish",
+		Short: "Print shell functions for 'orgit cd', the prompt and completion",
+		Long: `Print shell functions and completions to load in your shell's startup file:
+
+  bash  eval "$(orgit shell-init bash)"   in ~/.bashrc
+  zsh   eval "$(orgit shell-init zsh)"    in ~/.zshrc, after compinit
+  fish  orgit shell-init fish | source    in ~/.config/fish/config.fish
+
+This defines:
+  orgit         wraps orgit so that 'orgit cd QUERY' changes the shell's
+                directory to the best matching repo
+  orgit_prompt  prints the current directory relative to the workspace, for
+                use in a prompt, e.g. PS1='$(orgit_prompt) \$ '
+
+and loads the completions of 'orgit completion', which complete repo names
+from the workspace and from the repo listings cached by 'orgit sync'.
+`,
+		ValidArgs: []string{"bash", "zsh", "fish"},
+		Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		Run: func(cmd *cobra.Command, args []string) {
+			err := writeShellInit(os.Stdout, args[0])
+			if err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+		},
+	}
+	rootCmd.AddCommand(cmdShellInit)
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFindRepoDirs(t *testing.T) {
+	workspace := t.TempDir()
+	t.Setenv("ORGIT_WORKSPACE", workspace)
+	for _, dir := range []string{"github.com/org/api", "github.com/org/api-gateway", "github.com/apple/pie"} {
+		err := os.MkdirAll(filepath.Join(workspace, dir, ".git"), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := map[string][]string{
+		"":                                   {workspace},
+		"github.com/org/api-gateway":         {filepath.Join(workspace, "github.com/org/api-gateway")},
+		"https://github.com/org/api.git":     {filepath.Join(workspace, "github.com/org/api")},
+		"api":                                {filepath.Join(workspace, "github.com/org/api"), filepath.Join(workspace, "github.com/org/api-gateway"), filepath.Join(workspace, "github.com/apple/pie")},
+		"gateway":                            {filepath.Join(workspace, "github.com/org/api-gateway")},
+		"nothing":                            {},
+		"github.com/org/api-gateway/missing": {},
+	}
+	for query, want := range tests {
+		got := findRepoDirs(query)
+		if !slices.Equal(got, want) {
+			t.Errorf("findRepoDirs(%q) = %v, want %v", query, got, want)
+		}
+	}
+}
+
+func TestShellInitBash(t *testing.T) {
+	workspace := t.TempDir()
+	t.Setenv("ORGIT_WORKSPACE", workspace)
+	repoDir := filepath.Join(workspace, "github.com/org/api")
+	err := os.MkdirAll(repoDir, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a stand-in for the orgit binary, as 'orgit cd' prints the repo's directory
+	binDir := t.TempDir()
+	writeTestFile(t, filepath.Join(binDir, "orgit"), "#!/bin/sh\n[ \"$1\" = cd ] && echo "+shellQuote(repoDir)+"\n")
+	err = os.Chmod(filepath.Join(binDir, "orgit"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	script := strings.Builder{}
+	err = writeShellInit(&script, "bash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(binDir, "init.bash"), script.String())
+
+	cmd := exec.Command("bash", "-c", `source "$1" && unset ORGIT_WORKSPACE && orgit cd api && pwd && orgit_prompt`, "bash", filepath.Join(binDir, "init.bash"))
+	cmd.Env = append(os.Environ(), "PATH="+binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%s: %s", err, out)
+	}
+	want := repoDir + "\ngithub.com/org/api"
+	if strings.TrimSpace(string(out)) != want {
+		t.Errorf("got %q, want %q", out, want)
+	}
+
+	err = writeShellInit(&script, "powershell")
+	if err == nil {
+		t.Error("expected an error for an unsupported shell")
+	}
+}
+
+func TestFishQuote(t *testing.T) {
+	got := fishQuote(`/it's/a\path`)
+	want := `'/it\'s/a\\path'`
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sourcegraph/conc/pool"
+	"github.com/spf13/cobra"
+)
+
+// orgitStashMessage is the message of stashes created when updating a repo
+const orgitStashMessage = "orgit"
+
+// orgitStash is a stash created by orgit when updating a repo
+type orgitStash struct {
+	Repo    string    `json:"repo"`
+	Ref     string    `json:"ref"`
+	Branch  string    `json:"branch"`
+	Created time.Time `json:"created"`
+}
+
+// parseOrgitStashes parses the output of `git stash list --format=%gd%x09%ct%x09%gs`,
+// returning only the stashes created by orgit
+func parseOrgitStashes(repo string, lines []string) []orgitStash {
+	stashes := []orgitStash{}
+	for _, line := range lines {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 || !isOrgitStash(fields[2]) {
+			continue
+		}
+		created, _ := strconv.ParseInt(fields[1], 10, 64)
+
+		// the subject is "On BRANCH: orgit"
+		branch := strings.TrimSuffix(fields[2], ": "+orgitStashMessage)
+		branch = strings.TrimPrefix(branch, "On ")
+
+		stashes = append(stashes, orgitStash{
+			Repo:    repo,
+			Ref:     fields[0],
+			Branch:  branch,
+			Created: time.Unix(created, 0),
+		})
+	}
+	return stashes
+}
+
+func listOrgitStashes(dir string) ([]orgitStash, error) {
+	lines, err := doExecQuietLines(dir, "git stash list --format=%gd%x09%ct%x09%gs")
+	if err != nil {
+		return nil, err
+	}
+	return parseOrgitStashes(relativeToWorkspace(dir), lines), nil
+}
+
+// collectOrgitStashes finds the orgit stashes in every repo under baseDir concurrently
+func collectOrgitStashes(baseDir string) []orgitStash {
+	mu := sync.Mutex{}
+	stashes := []orgitStash{}
+	p := pool.New().WithMaxGoroutines(SyncWorkerPoolSize)
+	forEachGitDirIn(baseDir, func(relativeDir string) {
+		p.Go(func() {
+			s, err := listOrgitStashes(filepath.Join(baseDir, relativeDir))
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			stashes = append(stashes, s...)
+		})
+	})
+	p.Wait()
+
+	// repos in order, stashes newest first as in `git stash list`
+	slices.SortStableFunc(stashes, func(a, b orgitStash) int {
+		return strings.Compare(a.Repo, b.Repo)
+	})
+	return stashes
+}
+
+func printStashesTable(stashes []orgitStash) {
+	now := time.Now()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REPO\tSTASH\tBRANCH\tAGE")
+	for _, s := range stashes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Repo, s.Ref, s.Branch, formatAge(now.Sub(s.Created)))
+	}
+	w.Flush()
+}
+
+func init() {
+	var jsonFlag bool
+
+	var cmdStashes = &cobra.Command{
+		Use:   "stashes [DIR]",
+		Short: "List the stashes orgit created when updating repositories",
+		Long: `List the stashes orgit created when updating each repository in DIR, or in the
+workspace path if DIR is not specified, along with the branch they were made on.
+
+Stashed changes can be re-applied with 'git stash pop STASH' in the repository,
+or automatically during an update with 'orgit sync --restore-stash' or
+'orgit get --update --restore-stash'.
+`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			baseDir := getWorkspaceDir()
+			if len(args) == 1 {
+				baseDir = resolveWorkspacePath(baseDir, args[0])
+			}
+			if !dirExists(baseDir) {
+				cmd.PrintErrf("'%s' does not exist\n", baseDir)
+				os.Exit(1)
+			}
+
+			stashes := collectOrgitStashes(baseDir)
+			if jsonFlag {
+				err := printJson(stashes)
+				if err != nil {
+					cmd.PrintErrln(err)
+					os.Exit(1)
+				}
+				return
+			}
+			printStashesTable(stashes)
+		},
+	}
+
+	cmdStashes.Flags().BoolVar(&jsonFlag, "json", false, "Output as JSON")
+	rootCmd.AddCommand(cmdStashes)
+}
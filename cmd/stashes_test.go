@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseOrgitStashes(t *testing.T) {
+	lines := []string{
+		"stash@{0}\t1700000000\tOn main: orgit",
+		"stash@{1}\t1600000000\tOn main: my own stash",
+		"stash@{2}\t1500000000\tOn feature/x: orgit",
+		"stash@{3}\t1400000000\tWIP on main: 3bd28e0 initial",
+	}
+
+	stashes := parseOrgitStashes("github.com/org/repo", lines)
+	expected := []orgitStash{
+		{Repo: "github.com/org/repo", Ref: "stash@{0}", Branch: "main", Created: time.Unix(1700000000, 0)},
+		{Repo: "github.com/org/repo", Ref: "stash@{2}", Branch: "feature/x", Created: time.Unix(1500000000, 0)},
+	}
+	if len(stashes) != len(expected) {
+		t.Fatalf("Expected %d stashes, got %+v", len(expected), stashes)
+	}
+	for i := range expected {
+		if stashes[i] != expected[i] {
+			t.Errorf("Expected %+v, got %+v", expected[i], stashes[i])
+		}
+	}
+}
+
+func TestDoUpdateRestoreStash(t *testing.T) {
+	remote, local := newTestRepoWithRemote(t)
+	other := filepath.Join(t.TempDir(), "other")
+	gitInTest(t, local, "remote", "set-head", "origin", "main")
+	gitInTest(t, filepath.Dir(other), "clone", "--quiet", remote, other)
+
+	pushChange := func(file, content string) {
+		writeTestFile(t, filepath.Join(other, file), content)
+		gitInTest(t, other, "add", file)
+		gitInTest(t, other, "commit", "--quiet", "-m", file)
+		gitInTest(t, other, "push", "--quiet", "origin", "main")
+	}
+
+	remoteUrl, _ := url.Parse(remote)
+	c := getCmdContext{
+		Stdout:       io.Discard,
+		Stderr:       io.Discard,
+		CmdEchoFunc:  func(cmd, dir string) {},
+		WorkingDir:   local,
+		RestoreStash: true,
+	}
+
+	// changes that don't conflict are restored
+	pushChange("a", "remote")
+	writeTestFile(t, filepath.Join(local, "b"), "local")
+	err := c.doUpdate(remoteUrl, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fileExists(filepath.Join(local, "a")) {
+		t.Error("Expected repo to be fast-forwarded")
+	}
+	if b, _ := os.ReadFile(filepath.Join(local, "b")); string(b) != "local" {
+		t.Error("Expected stashed changes to be restored")
+	}
+	stashes, err := listOrgitStashes(local)
+	if err != nil || len(stashes) != 0 {
+		t.Errorf("Expected no stashes left, got %+v %v", stashes, err)
+	}
+
+	// changes that conflict are left in the stash
+	pushChange("b", "remote")
+	err = c.doUpdate(remoteUrl, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(filepath.Join(local, "b")); string(b) != "remote" {
+		t.Error("Expected repo to be fast-forwarded")
+	}
+	stashes, err = listOrgitStashes(local)
+	if err != nil || len(stashes) != 1 || stashes[0].Branch != "main" {
+		t.Errorf("Expected one orgit stash from main, got %+v %v", stashes, err)
+	}
+}
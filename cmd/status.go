@@ -0,0 +1,324 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sourcegraph/conc/pool"
+	"github.com/spf13/cobra"
+)
+
+// repoStatus is a summary of the git state of a local repo
+type repoStatus struct {
+	Repo                string     `json:"repo"`
+	Branch              string     `json:"branch,omitempty"`
+	DefaultBranch       string     `json:"default_branch,omitempty"`
+	Detached            bool       `json:"detached"`
+	Upstream            string     `json:"upstream,omitempty"`
+	Ahead               int        `json:"ahead"`
+	Behind              int        `json:"behind"`
+	Uncommitted         int        `json:"uncommitted"`
+	Untracked           int        `json:"untracked"`
+	OrgitStashes        int        `json:"orgit_stashes"`
+	Submodules          int        `json:"submodules"`
+	SubmodulesOutOfSync int        `json:"submodules_out_of_sync"`
+	LfsFiles            int        `json:"lfs_files"`
+	LfsMissing          int        `json:"lfs_missing"`
+	Locked              bool       `json:"locked"`
+	LastFetch           *time.Time `json:"last_fetch,omitempty"`
+	Error               string     `json:"error,omitempty"`
+}
+
+func (s repoStatus) IsDirty() bool {
+	return s.Uncommitted > 0 || s.Untracked > 0
+}
+
+func (s repoStatus) IsOffDefaultBranch() bool {
+	return s.Detached || (s.DefaultBranch != "" && s.Branch != s.DefaultBranch)
+}
+
+// doExecQuietLines executes shCmd in dir and returns its output as lines
+func doExecQuietLines(dir string, shCmd string) ([]string, error) {
+	cmd := newShellCmd(shCmd)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s: %w", dir, shCmd, err)
+	}
+	trimmed := strings.TrimRight(string(out), "\n")
+	if trimmed == "" {
+		return nil, nil
+	}
+	return strings.Split(trimmed, "\n"), nil
+}
+
+// parseStatusPorcelainV2 fills in the branch and worktree fields of s from
+// the output of `git status --porcelain=v2 --branch`
+func parseStatusPorcelainV2(s *repoStatus, lines []string) {
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "# branch.head "):
+			head := strings.TrimPrefix(line, "# branch.head ")
+			if head == "(detached)" {
+				s.Detached = true
+			} else {
+				s.Branch = head
+			}
+		case strings.HasPrefix(line, "# branch.upstream "):
+			s.Upstream = strings.TrimPrefix(line, "# branch.upstream ")
+		case strings.HasPrefix(line, "# branch.ab "):
+			fields := strings.Fields(strings.TrimPrefix(line, "# branch.ab "))
+			if len(fields) == 2 {
+				s.Ahead, _ = strconv.Atoi(strings.TrimPrefix(fields[0], "+"))
+				s.Behind, _ = strconv.Atoi(strings.TrimPrefix(fields[1], "-"))
+			}
+		case strings.HasPrefix(line, "? "):
+			s.Untracked++
+		case strings.HasPrefix(line, "1 "), strings.HasPrefix(line, "2 "), strings.HasPrefix(line, "u "):
+			s.Uncommitted++
+		}
+	}
+}
+
+func isOrgitStash(stashListLine string) bool {
+	return strings.HasSuffix(stashListLine, ": "+orgitStashMessage)
+}
+
+func getRepoStatus(baseDir, relativeDir string) repoStatus {
+	dir := filepath.Join(baseDir, relativeDir)
+	s := repoStatus{
+		Repo:   relativeToWorkspace(dir),
+		Locked: fileExists(gitPath(dir, "index.lock")),
+	}
+
+	lines, err := doExecQuietLines(dir, "git status --porcelain=v2 --branch")
+	if err != nil {
+		s.Error = err.Error()
+		return s
+	}
+	parseStatusPorcelainV2(&s, lines)
+
+	defaultBranch, err := doExecQuietLines(dir, "git symbolic-ref --short refs/remotes/origin/HEAD")
+	if err == nil && len(defaultBranch) == 1 {
+		s.DefaultBranch = strings.TrimPrefix(defaultBranch[0], "origin/")
+	}
+
+	stashes, err := doExecQuietLines(dir, "git stash list")
+	if err == nil {
+		for _, stash := range stashes {
+			if isOrgitStash(stash) {
+				s.OrgitStashes++
+			}
+		}
+	}
+
+	if hasSubmodules(dir) {
+		submodules, err := doExecQuietLines(dir, "git submodule status --recursive")
+		if err == nil {
+			s.Submodules, s.SubmodulesOutOfSync = parseSubmoduleStatus(submodules)
+		}
+	}
+
+	if usesLfs(dir) && isLfsInstalled() {
+		lfsFiles, err := doExecQuietLines(dir, "git lfs ls-files")
+		if err == nil {
+			s.LfsFiles, s.LfsMissing = parseLfsLsFiles(lfsFiles)
+		}
+	}
+
+	if info, err := os.Stat(gitCommonPath(dir, "FETCH_HEAD")); err == nil {
+		lastFetch := info.ModTime()
+		s.LastFetch = &lastFetch
+	}
+
+	return s
+}
+
+type statusFilter struct {
+	dirty      bool
+	ahead      bool
+	behind     bool
+	detached   bool
+	offDefault bool
+	stashed    bool
+	locked     bool
+}
+
+// matches returns true if s satisfies all of the enabled filters
+func (f statusFilter) matches(s repoStatus) bool {
+	return (!f.dirty || s.IsDirty()) &&
+		(!f.ahead || s.Ahead > 0) &&
+		(!f.behind || s.Behind > 0) &&
+		(!f.detached || s.Detached) &&
+		(!f.offDefault || s.IsOffDefaultBranch()) &&
+		(!f.stashed || s.OrgitStashes > 0) &&
+		(!f.locked || s.Locked)
+}
+
+func formatLastFetch(t *time.Time, now time.Time) string {
+	if t == nil {
+		return "never"
+	}
+	return fmt.Sprintf("%s ago", formatAge(now.Sub(*t)))
+}
+
+// formatAge formats d in the largest whole unit, e.g. "3d" or "5m"
+func formatAge(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	default:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+}
+
+// formatCountWithProblems formats n, with the number of problems if any, e.g. "3 (1 missing)"
+func formatCountWithProblems(n, problems int, problem string) string {
+	if n == 0 {
+		return ""
+	}
+	if problems == 0 {
+		return strconv.Itoa(n)
+	}
+	return fmt.Sprintf("%d (%d %s)", n, problems, problem)
+}
+
+func printStatusTable(statuses []repoStatus) {
+	now := time.Now()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REPO\tBRANCH\tDEFAULT\tAHEAD\tBEHIND\tCHANGED\tUNTRACKED\tSTASHES\tSUBMODULES\tLFS\tLOCKED\tFETCHED")
+	for _, s := range statuses {
+		if s.Error != "" {
+			fmt.Fprintf(w, "%s\terror: %s\n", s.Repo, cleanString(s.Error))
+			continue
+		}
+		branch := s.Branch
+		if s.Detached {
+			branch = "(detached)"
+		}
+		aheadBehind := []string{"-", "-"}
+		if s.Upstream != "" {
+			aheadBehind = []string{strconv.Itoa(s.Ahead), strconv.Itoa(s.Behind)}
+		}
+		locked := ""
+		if s.Locked {
+			locked = "yes"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%d\t%d\t%s\t%s\t%s\t%s\n",
+			s.Repo, branch, s.DefaultBranch, aheadBehind[0], aheadBehind[1],
+			s.Uncommitted, s.Untracked, s.OrgitStashes,
+			formatCountWithProblems(s.Submodules, s.SubmodulesOutOfSync, "out of sync"),
+			formatCountWithProblems(s.LfsFiles, s.LfsMissing, "missing"),
+			locked, formatLastFetch(s.LastFetch, now))
+	}
+	w.Flush()
+}
+
+// collectRepoStatuses gets the status of every repo under baseDir concurrently
+func collectRepoStatuses(baseDir string, filter statusFilter) []repoStatus {
+	mu := sync.Mutex{}
+	statuses := []repoStatus{}
+	p := pool.New().WithMaxGoroutines(SyncWorkerPoolSize)
+	forEachGitDirIn(baseDir, func(relativeDir string) {
+		p.Go(func() {
+			s := getRepoStatus(baseDir, relativeDir)
+			if s.Error == "" && !filter.matches(s) {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			statuses = append(statuses, s)
+		})
+	})
+	p.Wait()
+
+	slices.SortFunc(statuses, func(a, b repoStatus) int {
+		return strings.Compare(a.Repo, b.Repo)
+	})
+	return statuses
+}
+
+func init() {
+	filter := statusFilter{}
+	var archived bool
+	var jsonFlag bool
+
+	var cmdStatus = &cobra.Command{
+		Use:   "status [DIR]",
+		Short: "Show the git status of repositories",
+		Long: `Show the git status of each repository in DIR, or in the workspace path if DIR is not specified.
+
+For each repository the current and default branch, commits ahead and behind
+the upstream, uncommitted and untracked files, stashes created by orgit,
+submodules not checked out at the recorded commit, LFS files whose objects
+haven't been downloaded, a stale .git/index.lock and the last fetch time are
+reported.
+`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			baseDir := getWorkspaceDir()
+			if archived {
+				baseDir = filepath.Join(baseDir, archiveDir)
+			}
+			if len(args) == 1 {
+				baseDir = resolveWorkspacePath(baseDir, args[0])
+			}
+			if !dirExists(baseDir) {
+				cmd.PrintErrf("'%s' does not exist\n", baseDir)
+				os.Exit(1)
+			}
+
+			statuses := collectRepoStatuses(baseDir, filter)
+			if jsonFlag {
+				err := printJson(statuses)
+				if err != nil {
+					cmd.PrintErrln(err)
+					os.Exit(1)
+				}
+				return
+			}
+			printStatusTable(statuses)
+		},
+	}
+
+	cmdStatus.Flags().BoolVar(&filter.dirty, "dirty", false, "Filter by repositories with uncommitted or untracked changes")
+	cmdStatus.Flags().BoolVar(&filter.ahead, "ahead", false, "Filter by repositories with commits not pushed upstream")
+	cmdStatus.Flags().BoolVar(&filter.behind, "behind", false, "Filter by repositories behind their upstream")
+	cmdStatus.Flags().BoolVar(&filter.detached, "detached", false, "Filter by repositories with a detached HEAD")
+	cmdStatus.Flags().BoolVar(&filter.offDefault, "off-default", false, "Filter by repositories not on their default branch")
+	cmdStatus.Flags().BoolVar(&filter.stashed, "stashed", false, "Filter by repositories with stashes created by orgit")
+	cmdStatus.Flags().BoolVar(&filter.locked, "locked", false, "Filter by repositories with a .git/index.lock")
+	cmdStatus.Flags().BoolVar(&archived, "archived", false, "Show archived repositories")
+	cmdStatus.Flags().BoolVar(&jsonFlag, "json", false, "Output as JSON")
+	rootCmd.AddCommand(cmdStatus)
+}
+
+// resolveWorkspacePath returns dir as an absolute path, treating relative
+// paths as relative to baseDir unless they exist relative to the current
+// directory. dir can also be the ssh, https or git protocol URL of a repo.
+func resolveWorkspacePath(baseDir, dir string) string {
+	if filepath.IsAbs(dir) {
+		return dir
+	}
+	if gitUrl, err := getRemoteGitUrl(dir); err == nil {
+		return filepath.Join(baseDir, filepath.FromSlash(repoNameFromGitUrl(gitUrl).String()))
+	}
+	if dirExists(dir) {
+		abs, err := filepath.Abs(dir)
+		if err == nil {
+			return abs
+		}
+	}
+	return filepath.Join(baseDir, dir)
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseStatusPorcelainV2(t *testing.T) {
+	out := `# branch.oid 3bd28e07d1b6c5b5bbd0a6e1e8f7a7e1b4c1d2e3
+# branch.head feature
+# branch.upstream origin/feature
+# branch.ab +2 -3
+1 .M N... 100644 100644 100644 3b18e512dba79e4c8300dd08aeb37f8e728b8dad 3b18e512dba79e4c8300dd08aeb37f8e728b8dad README
+2 R. N... 100644 100644 100644 3b18e512dba79e4c8300dd08aeb37f8e728b8dad 3b18e512dba79e4c8300dd08aeb37f8e728b8dad R100 new	old
+? untracked1
+? untracked2
+? untracked3`
+
+	s := repoStatus{DefaultBranch: "main"}
+	parseStatusPorcelainV2(&s, strings.Split(out, "\n"))
+
+	expected := repoStatus{
+		DefaultBranch: "main",
+		Branch:        "feature",
+		Upstream:      "origin/feature",
+		Ahead:         2,
+		Behind:        3,
+		Uncommitted:   2,
+		Untracked:     3,
+	}
+	if s != expected {
+		t.Errorf("Expected %+v, got %+v", expected, s)
+	}
+	if !s.IsDirty() || !s.IsOffDefaultBranch() {
+		t.Errorf("Expected repo to be dirty and off the default branch")
+	}
+}
+
+func TestParseStatusPorcelainV2Detached(t *testing.T) {
+	out := `# branch.oid 3bd28e07d1b6c5b5bbd0a6e1e8f7a7e1b4c1d2e3
+# branch.head (detached)`
+
+	s := repoStatus{DefaultBranch: "main"}
+	parseStatusPorcelainV2(&s, strings.Split(out, "\n"))
+
+	if !s.Detached || s.Branch != "" || s.Upstream != "" {
+		t.Errorf("Expected detached HEAD with no branch, got %+v", s)
+	}
+	if s.IsDirty() || !s.IsOffDefaultBranch() {
+		t.Errorf("Expected clean repo off the default branch, got %+v", s)
+	}
+}
+
+func TestStatusFilterMatches(t *testing.T) {
+	clean := repoStatus{Branch: "main", DefaultBranch: "main"}
+	dirtyAhead := repoStatus{Branch: "main", DefaultBranch: "main", Ahead: 1, Untracked: 1}
+	stashed := repoStatus{Branch: "feature", DefaultBranch: "main", OrgitStashes: 2}
+
+	tests := []struct {
+		name     string
+		filter   statusFilter
+		status   repoStatus
+		expected bool
+	}{
+		{"no filter", statusFilter{}, clean, true},
+		{"dirty clean", statusFilter{dirty: true}, clean, false},
+		{"dirty dirty", statusFilter{dirty: true}, dirtyAhead, true},
+		{"dirty and ahead", statusFilter{dirty: true, ahead: true}, dirtyAhead, true},
+		{"dirty and behind", statusFilter{dirty: true, behind: true}, dirtyAhead, false},
+		{"stashed", statusFilter{stashed: true}, stashed, true},
+		{"off default", statusFilter{offDefault: true}, stashed, true},
+		{"off default clean", statusFilter{offDefault: true}, clean, false},
+	}
+
+	for _, tt := range tests {
+		if result := tt.filter.matches(tt.status); result != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, result)
+		}
+	}
+}
+
+func TestGetRepoStatusWorktree(t *testing.T) {
+	_, local := newTestRepoWithRemote(t)
+	t.Setenv("ORGIT_WORKSPACE", filepath.Dir(local))
+	gitInTest(t, local, "fetch", "--quiet", "origin")
+	worktree := filepath.Join(filepath.Dir(local), "worktree")
+	gitInTest(t, local, "worktree", "add", "--quiet", "-b", "feature", worktree)
+
+	// a worktree's .git is a file, its index.lock is in the repo's git dir
+	gitDir, err := doExecQuietLines(worktree, "git rev-parse --absolute-git-dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, filepath.Join(gitDir[0], "index.lock"), "")
+
+	s := getRepoStatus(filepath.Dir(local), "worktree")
+	if s.Error != "" {
+		t.Fatal(s.Error)
+	}
+	if s.Branch != "feature" || !s.Locked {
+		t.Errorf("Expected the worktree on feature to be locked, got %+v", s)
+	}
+	if s.LastFetch == nil {
+		t.Error("Expected the worktree to have the repo's last fetch time")
+	}
+
+	s = getRepoStatus(filepath.Dir(local), "local")
+	if s.Locked || s.LastFetch == nil {
+		t.Errorf("Expected the repo to be fetched and not locked, got %+v", s)
+	}
+
+	err = os.Remove(filepath.Join(gitDir[0], "index.lock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := getRepoStatus(filepath.Dir(local), "worktree"); s.Locked {
+		t.Errorf("Expected the worktree not to be locked, got %+v", s)
+	}
+}
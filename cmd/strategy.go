@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const (
+	// updateStrategyDefault stashes changes, checks out the default branch and fast-forwards it
+	updateStrategyDefault = "default"
+	// updateStrategyFetchOnly only fetches, leaving the worktree untouched
+	updateStrategyFetchOnly = "fetch-only"
+	// updateStrategyFFCurrent fast-forwards the checked out branch to its upstream
+	updateStrategyFFCurrent = "ff-current"
+	// updateStrategyRebase is like ff-current but rebases unpushed commits onto the upstream
+	updateStrategyRebase = "rebase"
+)
+
+var updateStrategies = []string{updateStrategyDefault, updateStrategyFetchOnly, updateStrategyFFCurrent, updateStrategyRebase}
+
+func validateUpdateStrategy(s string) error {
+	if !slices.Contains(updateStrategies, s) {
+		return fmt.Errorf("unknown update strategy '%s', must be one of %s", s, strings.Join(updateStrategies, ", "))
+	}
+	return nil
+}
+
+// updateStrategySelector chooses the update strategy for each repo, preferring
+// the --strategy flag, then the most specific target in the workspace config
+type updateStrategySelector struct {
+	flag    string
+	targets map[string]string
+}
+
+func newUpdateStrategySelector(flag string) (updateStrategySelector, error) {
+	if flag != "" {
+		if err := validateUpdateStrategy(flag); err != nil {
+			return updateStrategySelector{}, err
+		}
+	}
+
+	targets, err := readSubsectionConfig("target", "strategy")
+	if err != nil {
+		return updateStrategySelector{}, err
+	}
+	for target, s := range targets {
+		if err := validateUpdateStrategy(s); err != nil {
+			return updateStrategySelector{}, fmt.Errorf("%s: target '%s': %w", workspaceConfigFile, target, err)
+		}
+	}
+
+	return updateStrategySelector{flag: flag, targets: targets}, nil
+}
+
+func (s updateStrategySelector) For(localDir string) string {
+	if s.flag != "" {
+		return s.flag
+	}
+	if strategy, ok := matchTarget(s.targets, filepath.ToSlash(relativeToWorkspace(localDir))); ok {
+		return strategy
+	}
+	return updateStrategyDefault
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpdateStrategySelector(t *testing.T) {
+	workspace := t.TempDir()
+	t.Setenv("ORGIT_WORKSPACE", workspace)
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+	writeTestFile(t, filepath.Join(workspace, workspaceConfigFile), `
+[target "github.com/org"]
+	strategy = ff-current
+[target "github.com/org/sub"]
+	strategy = fetch-only
+[target "https://gitlab.com/Group/"]
+	strategy = rebase
+`)
+
+	s, err := newUpdateStrategySelector("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"github.com/org/repo":       updateStrategyFFCurrent,
+		"github.com/org/sub/repo":   updateStrategyFetchOnly,
+		"github.com/org/subrepo":    updateStrategyFFCurrent,
+		"github.com/organisation/x": updateStrategyDefault,
+		"gitlab.com/Group/repo":     updateStrategyRebase,
+	}
+	for repo, expected := range tests {
+		if actual := s.For(filepath.Join(workspace, repo)); actual != expected {
+			t.Errorf("%s: expected strategy %s, got %s", repo, expected, actual)
+		}
+	}
+
+	s, err = newUpdateStrategySelector(updateStrategyDefault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual := s.For(filepath.Join(workspace, "github.com/org/repo")); actual != updateStrategyDefault {
+		t.Errorf("expected --strategy to override the config, got %s", actual)
+	}
+
+	if _, err = newUpdateStrategySelector("merge"); err == nil {
+		t.Error("expected an error for an unknown strategy")
+	}
+	writeTestFile(t, filepath.Join(workspace, workspaceConfigFile), "[target \"github.com/org\"]\n\tstrategy = merge\n")
+	if _, err = newUpdateStrategySelector(""); err == nil || !strings.Contains(err.Error(), "github.com/org") {
+		t.Errorf("expected an error naming the misconfigured target, got %v", err)
+	}
+}
+
+func TestDoUpdateStrategies(t *testing.T) {
+	remote, local := newTestRepoWithRemote(t)
+	other := filepath.Join(t.TempDir(), "other")
+	gitInTest(t, filepath.Dir(other), "clone", "--quiet", remote, other)
+
+	pushChange := func(file string) {
+		writeTestFile(t, filepath.Join(other, file), file)
+		gitInTest(t, other, "add", file)
+		gitInTest(t, other, "commit", "--quiet", "-m", file)
+		gitInTest(t, other, "push", "--quiet", "origin", "HEAD")
+	}
+	head := func(rev string) string {
+		out, err := doExecQuietWithOutput(local, "git rev-parse "+rev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(out)
+	}
+
+	// a feature branch tracking its upstream
+	gitInTest(t, other, "checkout", "--quiet", "-b", "feature")
+	pushChange("f1")
+	gitInTest(t, local, "fetch", "--quiet")
+	gitInTest(t, local, "checkout", "--quiet", "feature")
+	pushChange("f2")
+
+	remoteUrl, _ := url.Parse(remote)
+	c := getCmdContext{
+		Stdout:      io.Discard,
+		Stderr:      io.Discard,
+		CmdEchoFunc: func(cmd, dir string) {},
+		InfoFunc:    func(msg string) {},
+		WorkingDir:  local,
+	}
+
+	c.Strategy = updateStrategyFetchOnly
+	if err := c.doUpdate(remoteUrl, "main"); err != nil {
+		t.Fatal(err)
+	}
+	if head("HEAD") == head("origin/feature") {
+		t.Error("fetch-only: expected the worktree not to be updated")
+	}
+
+	c.Strategy = updateStrategyFFCurrent
+	if err := c.doUpdate(remoteUrl, "main"); err != nil {
+		t.Fatal(err)
+	}
+	if head("HEAD") != head("origin/feature") {
+		t.Error("ff-current: expected feature to be fast-forwarded")
+	}
+
+	// diverged branches can only be updated by rebasing
+	gitInTest(t, local, "commit", "--quiet", "--allow-empty", "-m", "local")
+	pushChange("f3")
+	diverged := head("HEAD")
+	if err := c.doUpdate(remoteUrl, "main"); err != nil {
+		t.Fatal(err)
+	}
+	if head("HEAD") != diverged {
+		t.Error("ff-current: expected diverged branch to be left alone")
+	}
+	c.Strategy = updateStrategyRebase
+	if err := c.doUpdate(remoteUrl, "main"); err != nil {
+		t.Fatal(err)
+	}
+	if head("HEAD~1") != head("origin/feature") {
+		t.Error("rebase: expected local commit to be rebased onto origin/feature")
+	}
+	if branch, _ := doExecQuietWithOutput(local, "git rev-parse --abbrev-ref HEAD"); strings.TrimSpace(branch) != "feature" {
+		t.Errorf("rebase: expected to stay on feature, got %s", branch)
+	}
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+func hasSubmodules(dir string) bool {
+	return fileExists(filepath.Join(dir, ".gitmodules"))
+}
+
+// updateSubmodules syncs submodule URLs from .gitmodules and checks out the
+// submodule commits recorded in HEAD, returning false if there are no submodules
+func (c *getCmdContext) updateSubmodules() (bool, error) {
+	if !hasSubmodules(c.WorkingDir) {
+		return false, nil
+	}
+	err := c.echoEval(`git submodule sync --recursive`)
+	if err != nil {
+		return false, fmt.Errorf("error syncing submodules: %w", err)
+	}
+	err = c.echoEvalf(`git submodule update%s --init --recursive`, c.progressFlag())
+	if err != nil {
+		return false, fmt.Errorf("error updating submodules: %w", err)
+	}
+	return true, nil
+}
+
+// parseSubmoduleStatus counts the submodules in the output of
+// `git submodule status --recursive`, and those not checked out at the commit
+// recorded in the superproject
+func parseSubmoduleStatus(lines []string) (total, outOfSync int) {
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		total++
+		// ' ' is in sync, '-' not initialised, '+' different commit, 'U' conflicts
+		if !strings.HasPrefix(line, " ") {
+			outOfSync++
+		}
+	}
+	return total, outOfSync
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"errors"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseSubmoduleStatus(t *testing.T) {
+	lines := []string{
+		" 3bd28e07d1b6c5b5bbd0a6e1e8f7a7e1b4c1d2e3 lib/a (heads/main)",
+		"+4bd28e07d1b6c5b5bbd0a6e1e8f7a7e1b4c1d2e3 lib/b (heads/main)",
+		"-5bd28e07d1b6c5b5bbd0a6e1e8f7a7e1b4c1d2e3 lib/c",
+	}
+	total, outOfSync := parseSubmoduleStatus(lines)
+	if total != 3 || outOfSync != 2 {
+		t.Errorf("Expected 3 submodules with 2 out of sync, got %d and %d", total, outOfSync)
+	}
+}
+
+func TestParseLfsLsFiles(t *testing.T) {
+	lines := []string{
+		"3bd28e07d1 * assets/logo.png",
+		"4bd28e07d1 - assets/video.mp4",
+		"5bd28e07d1 - assets/with space.psd",
+	}
+	total, missing := parseLfsLsFiles(lines)
+	if total != 3 || missing != 2 {
+		t.Errorf("Expected 3 LFS files with 2 missing, got %d and %d", total, missing)
+	}
+}
+
+func TestPullLfsNotInstalled(t *testing.T) {
+	if isLfsInstalled() {
+		t.Skip("git-lfs is installed")
+	}
+	dir := t.TempDir()
+	c := getCmdContext{WorkingDir: dir, CmdEchoFunc: func(cmd, dir string) {}}
+	if pulled, err := c.pullLfs(); pulled || err != nil {
+		t.Errorf("Expected a repo without LFS to be skipped, got %v %v", pulled, err)
+	}
+	writeTestFile(t, filepath.Join(dir, ".gitattributes"), "*.png filter=lfs diff=lfs merge=lfs -text\n")
+	if _, err := c.pullLfs(); !errors.Is(err, errLfsNotInstalled) {
+		t.Errorf("Expected %v, got %v", errLfsNotInstalled, err)
+	}
+}
+
+func TestDoUpdateSubmodules(t *testing.T) {
+	remote, local := newTestRepoWithRemote(t)
+	gitInTest(t, local, "config", "--global", "protocol.file.allow", "always")
+
+	// a submodule with its own remote
+	subRemote := filepath.Join(filepath.Dir(remote), "sub.git")
+	subWork := filepath.Join(t.TempDir(), "sub")
+	gitInTest(t, local, "init", "--quiet", "--bare", "--initial-branch=main", subRemote)
+	gitInTest(t, local, "clone", "--quiet", subRemote, subWork)
+	gitInTest(t, subWork, "commit", "--quiet", "--allow-empty", "-m", "sub1")
+	gitInTest(t, subWork, "push", "--quiet", "origin", "main")
+
+	gitInTest(t, local, "submodule", "--quiet", "add", subRemote, "sub")
+	gitInTest(t, local, "commit", "--quiet", "-m", "add sub")
+	gitInTest(t, local, "push", "--quiet", "origin", "main")
+
+	remoteUrl, _ := url.Parse(remote)
+	clone := filepath.Join(t.TempDir(), "clone")
+	c := getCmdContext{
+		Stdout:      io.Discard,
+		Stderr:      io.Discard,
+		CmdEchoFunc: func(cmd, dir string) {},
+		WorkingDir:  clone,
+	}
+	if err := c.doClone(remote, ""); err != nil {
+		t.Fatal(err)
+	}
+	if c.WorkingDir != clone || !c.SubmodulesUpdated {
+		t.Errorf("Expected clone to update submodules in '%s'", clone)
+	}
+
+	// move the submodule forward upstream
+	gitInTest(t, subWork, "commit", "--quiet", "--allow-empty", "-m", "sub2")
+	gitInTest(t, subWork, "push", "--quiet", "origin", "main")
+	gitInTest(t, filepath.Join(local, "sub"), "pull", "--quiet")
+	gitInTest(t, local, "commit", "--quiet", "-am", "bump sub")
+	gitInTest(t, local, "push", "--quiet", "origin", "main")
+
+	if err := c.doUpdate(remoteUrl, "main"); err != nil {
+		t.Fatal(err)
+	}
+	status, err := doExecQuietLines(clone, "git submodule status --recursive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, outOfSync := parseSubmoduleStatus(status); outOfSync != 0 {
+		t.Errorf("Expected submodules to be in sync after update, got %s", strings.Join(status, "\n"))
+	}
+}
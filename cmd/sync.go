@@ -13,6 +13,7 @@ import (
 	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	ignore "github.com/sabhiram/go-gitignore"
 	"github.com/sourcegraph/conc/pool"
@@ -29,6 +30,17 @@ func init() {
 	noUpdateFlag := false
 	noArchiveFlag := false
 	tidyFlag := false
+	dashboardFlag := false
+	forceFlag := false
+	dryRunFlag := false
+	jsonFlag := false
+	waitFlag := false
+	restoreStashFlag := false
+	strategyFlag := ""
+	pruneBranchesFlag := false
+	divergedFlag := ""
+	skipLfsFlag := false
+	lfsPullFlag := []string{}
 
 	var cmdSync = &cobra.Command{
 		Use:   "sync [flags] ORG_URL",
@@ -36,24 +48,76 @@ func init() {
 		Short: `Clone and update all repos from a GitHub/GitLab user/org/group`,
 		Long: `Syncing will:
  1. clone all repositories from a GitHub/GitLab user/org/group
- 2. update local repos by stashing uncommitted changes and switching to origin HEAD
+ 2. update local repos by stashing uncommitted changes and switching to origin HEAD.
+    With --restore-stash the changes are re-applied if they apply cleanly
  3. archive local repos that have been archived remotely by moving them to $ORGIT_WORKSPACE/.archive
+
+How existing repos are updated depends on the update strategy:
+  default     stash uncommitted changes, checkout the default branch and fast-forward it
+  fetch-only  only fetch from the remote
+  ff-current  fast-forward the checked out branch if it tracks an upstream
+  rebase      like ff-current, but rebase unpushed commits onto the upstream
+
+The strategy can be set per target in $ORGIT_WORKSPACE/.orgitconfig, where the
+most specific matching target is used, and overridden with --strategy:
+
+  [target "github.com/org"]
+      strategy = ff-current
+
+A branch that can't be fast-forwarded because it has diverged from its upstream,
+with local commits, a force-pushed upstream or rewritten history, is handled
+with --diverged:
+  warn          leave the branch alone and print a warning
+  backup-reset  keep the local branch as orgit/backup/<date> and reset to the upstream
+  rebase        rebase local commits onto the upstream, aborting on conflict
+
+After cloning or updating, submodules are synced and updated recursively. Use
+--skip-lfs to leave LFS pointer files instead of downloading large objects, and
+--lfs-pull to download LFS objects only for matching repos, e.g.
+
+  orgit sync github.com/org --skip-lfs --lfs-pull 'github.com/org/assets'
+
+Repos with uncommitted changes, unpushed commits or stashes are never archived
+or trashed unless --force is used.
+
+With --dry-run, repos are listed and matched against .orgitignore as normal, but
+instead of cloning, updating or moving anything the planned actions are printed.
+Only read-only git commands are run.
+
+Every archive, trash and rename move is recorded so that it can be reverted
+with 'orgit undo'.
+
+The listed repos are cached in $ORGIT_WORKSPACE/.orgit/remotes, so shell
+completion can suggest repos that aren't cloned yet.
 `,
 		Run: func(cmd *cobra.Command, args []string) {
 			orgUrlArg := args[0]
-			err := doSync(cmd.Context(), orgUrlArg, !noCloneFlag, !noUpdateFlag, !noArchiveFlag, tidyFlag, logLevelFlag)
+			err := doSync(cmd.Context(), orgUrlArg, syncOptions{
+				clone:     !noCloneFlag,
+				update:    !noUpdateFlag,
+				archive:   !noArchiveFlag,
+				tidy:      tidyFlag,
+				logLevel:  logLevelFlag,
+				dashboard: dashboardFlag,
+				force:     forceFlag,
+				dryRun:    dryRunFlag,
+				json:      jsonFlag,
+				wait:      waitFlag,
+
+				restoreStash: restoreStashFlag,
+				strategy:     strategyFlag,
+
+				pruneBranches: pruneBranchesFlag,
+				diverged:      divergedFlag,
+				skipLfs:       skipLfsFlag,
+				lfsPull:       lfsPullFlag,
+			})
 			if err != nil {
 				fmt.Println(err)
 				os.Exit(1)
 			}
 		},
-		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
-			if len(args) != 0 {
-				return nil, cobra.ShellCompDirectiveNoFileComp
-			}
-			workspace := getWorkspaceDir()
-			return []string{workspace}, cobra.ShellCompDirectiveFilterDirs
-		},
+		ValidArgsFunction: completeFirstRepoArg(knownRepoNames, true),
 	}
 
 	cmdSync.Flags().BoolVar(&noCloneFlag, "no-clone", false, "Don't clone repos")
@@ -61,18 +125,105 @@ func init() {
 	cmdSync.Flags().BoolVar(&noArchiveFlag, "no-archive", false, "Don't archive repos to $ORGIT_WORSPACE/.archive")
 	cmdSync.Flags().BoolVar(&tidyFlag, "tidy", false, "Tidy up the workspace, moving repos missing on the remote to $ORGIT_WORSPACE/.trash")
 	cmdSync.Flags().StringVar(&logLevelFlag, "log-level", "info", "Set the log level (debug, verbose, info, quiet)")
+	cmdSync.Flags().BoolVar(&forceFlag, "force", false, "Archive and trash repos even if they have uncommitted changes, unpushed commits or stashes")
+	cmdSync.Flags().BoolVar(&dryRunFlag, "dry-run", false, "List repos and print the actions that would be taken without changing anything")
+	cmdSync.Flags().BoolVar(&jsonFlag, "json", false, "Print the --dry-run plan as JSON")
+	cmdSync.Flags().StringVar(&strategyFlag, "strategy", "", "How to update existing repos: default, fetch-only, ff-current or rebase. Overrides .orgitconfig")
+	cmdSync.Flags().StringVar(&divergedFlag, "diverged", divergedPolicyWarn, "What to do when a branch has diverged from its upstream: warn, backup-reset or rebase")
+	cmdSync.Flags().BoolVar(&skipLfsFlag, "skip-lfs", false, "Don't download LFS objects when cloning and updating, leaving pointer files")
+	cmdSync.Flags().StringArrayVar(&lfsPullFlag, "lfs-pull", nil, "Download LFS objects in repos whose path matches this gitignore-style pattern (can be repeated)")
+	cmdSync.Flags().BoolVar(&pruneBranchesFlag, "prune-branches", false, "After updating, delete local branches that are merged or whose upstream is gone, see 'orgit prune-branches'")
+	cmdSync.Flags().BoolVar(&restoreStashFlag, "restore-stash", false, "Re-apply stashed changes after fast-forwarding if they apply cleanly")
+	cmdSync.Flags().BoolVar(&waitFlag, "wait", false, "Wait for another orgit process to release the workspace lock instead of failing")
+	cmdSync.Flags().BoolVar(&dashboardFlag, "dashboard", false, "Show the repos currently syncing, slowest first, with their elapsed time and git phase")
 
 	rootCmd.AddCommand(cmdSync)
 }
 
-var dryRun = false
+type syncOptions struct {
+	clone     bool
+	update    bool
+	archive   bool
+	tidy      bool
+	logLevel  string
+	dashboard bool
+	force     bool
+	dryRun    bool
+	json      bool
+	wait      bool
+
+	restoreStash bool
+	strategy     string
+
+	pruneBranches bool
+	diverged      string
+	skipLfs       bool
+	lfsPull       []string
+}
+
+func doSync(ctx context.Context, orgUrlStr string, opts syncOptions) (err error) {
+	logger := NewProgressLogger(opts.logLevel)
+	if opts.dashboard {
+		logger.EnableDashboard()
+	}
+
+	strategies, err := newUpdateStrategySelector(opts.strategy)
+	if err != nil {
+		return err
+	}
+	transports, err := newTransportSelector()
+	if err != nil {
+		return err
+	}
+	err = validateDivergedPolicy(opts.diverged)
+	if err != nil {
+		return err
+	}
+	if opts.json && !opts.dryRun {
+		return errors.New("--json can only be used with --dry-run")
+	}
+
+	if !opts.dryRun {
+		lock, lerr := acquireWorkspaceLock(opts.wait, logger.Info)
+		if lerr != nil {
+			return lerr
+		}
+		defer lock.Release()
+	}
 
-func doSync(ctx context.Context, orgUrlStr string, clone, update, archive, tidy bool, loglevel string) (err error) {
 	ctx, ctxCancel := context.WithCancel(ctx)
 
-	logger := NewProgressLogger(loglevel)
+	var plan *syncPlan
+	var history *historyRecorder
+	var journal *moveJournal
+	if opts.dryRun {
+		plan = &syncPlan{}
+	} else {
+		runID := newRunID(time.Now())
+		var herr error
+		history, herr = openHistoryRecorder(runID, orgUrlStr)
+		if herr != nil {
+			logger.Info(fmt.Sprintf("Not recording sync history: %s", herr))
+		}
+		defer history.Close()
+		journal = newMoveJournal(runID, logger.Info)
+		defer journal.Close()
+	}
 
-	workerPool := NewSyncReposWorkerPool(ctx, clone, update, archive, logger)
+	workerPool := NewSyncReposWorkerPool(ctx, opts.clone, opts.update, opts.archive, logger)
+	workerPool.history = history
+	workerPool.journal = journal
+	workerPool.force = opts.force
+	workerPool.restoreStash = opts.restoreStash
+	workerPool.strategies = strategies
+	workerPool.transports = transports
+	workerPool.pruneBranches = opts.pruneBranches
+	workerPool.diverged = opts.diverged
+	workerPool.skipLfs = opts.skipLfs
+	if len(opts.lfsPull) > 0 {
+		workerPool.lfsPull = ignore.CompileIgnoreLines(opts.lfsPull...)
+	}
+	workerPool.plan = plan
 	var workerPoolWait = sync.OnceFunc(func() {
 		werr := workerPool.Wait()
 		if werr != nil {
@@ -112,31 +263,45 @@ func doSync(ctx context.Context, orgUrlStr string, clone, update, archive, tidy
 
 	logger.Info(fmt.Sprintf("Syncing to '%s'", repoPath.LocalPathAbsolute()))
 
-	err = repoProvider.ListRepos(ctx, repoPath.Path, archive, workerPool.remoteReposChan)
+	err = repoProvider.ListRepos(ctx, repoPath.Path, opts.archive, workerPool.remoteReposChan)
 	close(workerPool.remoteReposChan) // close the channel to signal that no more repos will be sent
 	if err != nil && !errors.Is(err, context.Canceled) {
 		err = fmt.Errorf("couldn't list repos for '%s': %w", orgUrlStr, err)
 	}
+	listed := err == nil
 
 	workerPoolWait()
-	if tidy && ctx.Err() != context.Canceled {
+	if listed && plan == nil && ctx.Err() == nil {
+		cerr := writeRemoteRepoListing(repoPath, workerPool.getAllRemoteRepoDetails(), time.Now())
+		if cerr != nil {
+			logger.Info(fmt.Sprintf("Not caching the repo listing: %s", cerr))
+		}
+	}
+	if opts.tidy && ctx.Err() != context.Canceled {
 		logger.Info("Tidying...")
 		tidier := TidyAction{
 			repoProvider: repoProvider,
 			logger:       logger,
+			history:      history,
+			journal:      journal,
+			force:        opts.force,
+			plan:         plan,
 			remoteRepos:  workerPool.getAllRemoteRepos(),
 		}
 		tidier.Tidy(ctx, repoPath)
 	}
 
+	if plan != nil {
+		perr := plan.Print(opts.json)
+		if err == nil {
+			err = perr
+		}
+	}
+
 	return err
 }
 
 func osMove(oldpath, newpath string) error {
-	if dryRun {
-		return nil
-	}
-
 	err := os.MkdirAll(filepath.Dir(newpath), 0755)
 	if err != nil {
 		return fmt.Errorf("couldn't create parent dir: %w", err)
@@ -151,6 +316,10 @@ func osMove(oldpath, newpath string) error {
 type TidyAction struct {
 	repoProvider RepoProvider
 	logger       *ProgressLogger
+	history      *historyRecorder
+	journal      *moveJournal
+	force        bool
+	plan         *syncPlan
 	remoteRepos  []string
 }
 
@@ -161,15 +330,19 @@ func (t *TidyAction) Tidy(ctx context.Context, repoPath RepoName) {
 			panic(err)
 		}
 
-		if !d.IsDir() {
-			return t.Trash(relativePath)
+		// checked first so that repos symlinked into the workspace are kept
+		if t.HasAlreadyProcessedRepo(relativePath) {
+			if !d.IsDir() {
+				return nil
+			}
+			return fs.SkipDir
 		}
 
-		if slices.Contains(ignoreDirs, d.Name()) {
-			return fs.SkipDir
+		if !d.IsDir() {
+			return t.Trash(relativePath, trashReasonNotAGitRepo)
 		}
 
-		if t.HasAlreadyProcessedRepo(relativePath) {
+		if slices.Contains(ignoreDirs, d.Name()) {
 			return fs.SkipDir
 		}
 
@@ -179,6 +352,11 @@ func (t *TidyAction) Tidy(ctx context.Context, repoPath RepoName) {
 
 		absolutePath := filepath.Join(getWorkspaceDir(), relativePath)
 
+		// worktrees belong to their repo, which may be elsewhere
+		if isWorktree(absolutePath) {
+			return fs.SkipDir
+		}
+
 		if isGitRepo(absolutePath) {
 			reponame := MustParseRepoName(relativePath)
 
@@ -194,7 +372,7 @@ func (t *TidyAction) Tidy(ctx context.Context, repoPath RepoName) {
 			return fs.SkipDir
 		}
 
-		err = t.Trash(relativePath)
+		err = t.Trash(relativePath, trashReasonNotAGitRepo)
 		if err != nil {
 			t.logger.Info(err.Error())
 		}
@@ -234,14 +412,34 @@ func (t *TidyAction) IsParentDirectoryForProcessedRepo(pathname string) bool {
 	return false
 }
 
-func (t *TidyAction) Trash(pathRelative string) error {
+func (t *TidyAction) Trash(pathRelative, reason string) error {
 	pathAbsolute := filepath.Join(getWorkspaceDir(), pathRelative)
 	trashPath := filepath.Join(getTrashDir(), pathRelative)
 
-	err := osMove(pathAbsolute, trashPath)
+	if t.plan != nil {
+		t.plan.Add(plannedAction{
+			Action: planActionTrash,
+			Path:   filepath.ToSlash(pathRelative),
+			To:     filepath.ToSlash(relativeToWorkspace(trashPath)),
+			Reason: reason,
+		})
+		return nil
+	}
+
+	historyEntry := t.history.BeginMove(historyActionTrash, pathAbsolute)
+	err := t.journal.Move(moveKindTrash, pathAbsolute, trashPath)
+	t.history.End(historyEntry, trashPath, err)
 	if err != nil {
 		return fmt.Errorf("couldn't move '%s' to '%s': %w", pathRelative, trashPath, err)
 	}
+	err = appendTrashRecord(trashRecord{
+		Path:      filepath.ToSlash(pathRelative),
+		TrashedAt: time.Now(),
+		Reason:    reason,
+	})
+	if err != nil {
+		t.logger.Info(err.Error())
+	}
 	t.logger.Info(fmt.Sprintf("Moved '%s' to '%s'", pathAbsolute, trashPath))
 
 	return nil
@@ -250,7 +448,17 @@ func (t *TidyAction) Trash(pathRelative string) error {
 func (t *TidyAction) doTidy(ctx context.Context, oldRepoName RepoName) error {
 	newRepo, err := t.repoProvider.GetRepo(ctx, oldRepoName.Path)
 	if errors.Is(err, ErrRepoNotFound) {
-		err = t.Trash(oldRepoName.String())
+		if !t.force {
+			err = checkSafeToMove(oldRepoName.LocalPathAbsolute(), "trashing")
+			if err != nil && t.plan != nil {
+				t.plan.Add(plannedAction{Action: planActionSkip, Path: oldRepoName.String(), Reason: err.Error()})
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+		err = t.Trash(oldRepoName.String(), trashReasonNotFound)
 		if err != nil {
 			return fmt.Errorf("couldn't trash '%s': %w", oldRepoName.String(), err)
 		} else {
@@ -262,7 +470,21 @@ func (t *TidyAction) doTidy(ctx context.Context, oldRepoName RepoName) error {
 	}
 
 	if newRepo.RepoName.LocalPathAbsolute() != oldRepoName.LocalPathAbsolute() {
-		err := osMove(oldRepoName.LocalPathAbsolute(), newRepo.RepoName.LocalPathAbsolute())
+		if t.plan != nil {
+			a := plannedAction{Action: planActionMove, Path: oldRepoName.String(), To: newRepo.RepoName.String()}
+			// the move fails if the new name exists, as it will if it's cloned before tidying
+			if dirExists(newRepo.RepoName.LocalPathAbsolute()) {
+				a = plannedAction{Action: planActionSkip, Path: a.Path, Reason: fmt.Sprintf("renamed to %s which already exists", a.To)}
+			} else if t.plan.Has(planActionClone, a.To) {
+				a = plannedAction{Action: planActionSkip, Path: a.Path, Reason: fmt.Sprintf("renamed to %s which will be cloned", a.To)}
+			}
+			t.plan.Add(a)
+			return nil
+		}
+
+		historyEntry := t.history.BeginMove(historyActionMove, oldRepoName.LocalPathAbsolute())
+		err := t.journal.Move(moveKindRename, oldRepoName.LocalPathAbsolute(), newRepo.RepoName.LocalPathAbsolute())
+		t.history.End(historyEntry, newRepo.RepoName.LocalPathAbsolute(), err)
 		if err != nil {
 			return fmt.Errorf("couldn't move '%s' to '%s': %w", oldRepoName.LocalPathAbsolute(), newRepo.RepoName.LocalPathAbsolute(), err)
 		}
@@ -284,6 +506,17 @@ type syncReposWorkerPool struct {
 	ignore                  *ignore.GitIgnore
 	remoteReposChan         chan RemoteRepo
 	remoteReposChanFinished chan bool
+	history                 *historyRecorder
+	journal                 *moveJournal
+	restoreStash            bool
+	strategies              updateStrategySelector
+	transports              transportSelector
+	pruneBranches           bool
+	diverged                string
+	skipLfs                 bool
+	lfsPull                 *ignore.GitIgnore
+	force                   bool
+	plan                    *syncPlan
 
 	remoteRepos sync.Map
 }
@@ -316,6 +549,14 @@ func (p *syncReposWorkerPool) getAllRemoteRepos() (repos []string) {
 	return
 }
 
+func (p *syncReposWorkerPool) getAllRemoteRepoDetails() (repos []RemoteRepo) {
+	p.remoteRepos.Range(func(key, value interface{}) bool {
+		repos = append(repos, value.(RemoteRepo))
+		return true
+	})
+	return
+}
+
 func (p *syncReposWorkerPool) createJob(r RemoteRepo) func(context.Context) error {
 	if r.RepoName.String() == "" {
 		panic("RepoName is empty")
@@ -360,6 +601,7 @@ type RemoteRepo struct {
 	CloneUrl      string
 	IsArchived    bool
 	DefaultBranch string
+	Description   string
 }
 
 func (p *syncReposWorkerPool) Wait() error {
@@ -382,20 +624,40 @@ func (p *syncReposWorkerPool) canIgnore(r RemoteRepo) bool {
 	return false
 }
 
-func (p *syncReposWorkerPool) doWork(r RemoteRepo) error {
+func (p *syncReposWorkerPool) doWork(r RemoteRepo) (err error) {
 	gitUrl, _ := url.Parse(r.CloneUrl)
 	localDir := getLocalDir(gitUrl)
 	localDirExists := dirExists(localDir)
+	gitUrl, useTransport := p.transports.Apply(gitUrl)
+
+	p.progressWriter.EventStartRepo(localDir)
+	if p.plan != nil {
+		p.planWork(r, gitUrl, localDir, localDirExists)
+		return nil
+	}
+
+	historyEntry := p.history.Begin(localDir)
+	defer func() { p.history.End(historyEntry, localDir, err) }()
 
 	if r.IsArchived {
 		if localDirExists {
 			if p.archiveRepos {
+				historyEntry.Action = historyActionArchive
 				err := p.archive(localDir)
+				unpushedErr := &unpushedWorkError{}
+				if errors.As(err, &unpushedErr) {
+					historyEntry.Action = historyActionSkip
+					p.progressWriter.Info(unpushedErr.Error())
+					p.progressWriter.EventSkippedRepo(localDir)
+					return nil
+				}
 				if err != nil {
+					p.progressWriter.EventSyncedRepoError(localDir)
 					return fmt.Errorf("couldn't archive '%s': %w", localDir, err)
 				}
 				p.progressWriter.EventArchivedRepo(localDir)
 			} else {
+				historyEntry.Action = historyActionSkip
 				p.progressWriter.EventSkippedRepo(localDir)
 			}
 		} else {
@@ -405,32 +667,60 @@ func (p *syncReposWorkerPool) doWork(r RemoteRepo) error {
 		return nil
 	}
 
+	strategy := p.strategies.For(localDir)
 	c := getCmdContext{
 		Stdout:      p.progressWriter.WriterFor(localDir),
 		Stderr:      p.progressWriter.WriterFor(localDir),
-		CmdEchoFunc: p.progressWriter.EventExecCmd,
+		CmdEchoFunc: func(cmd, _ string) { p.progressWriter.EventExecCmd(cmd, localDir) },
 		WorkingDir:  localDir,
+		Progress:    p.progressWriter.IsDashboardEnabled(),
+
+		Strategy:     strategy,
+		RestoreStash: p.restoreStash,
+		Prune:        p.pruneBranches,
+		Diverged:     p.diverged,
+		Transport:    useTransport,
+		LfsPull:      p.lfsPull != nil && p.lfsPull.MatchesPath(filepath.ToSlash(relativeToWorkspace(localDir))),
+		InfoFunc:     p.progressWriter.Info,
+	}
+	if p.skipLfs {
+		c.Env = append(c.Env, lfsSkipSmudgeEnv)
 	}
 	if localDirExists {
 		if p.updateRepos {
+			historyEntry.Action = historyActionUpdate
+			historyEntry.Strategy = strategy
 			err := c.doUpdate(gitUrl, r.DefaultBranch)
 			if err != nil {
 				p.progressWriter.EventSyncedRepoError(localDir)
-				return fmt.Errorf("error updating: %w", err)
+				return fmt.Errorf("error updating with strategy '%s': %w", strategy, err)
+			}
+			if p.pruneBranches {
+				p.pruneLocalBranches(localDir)
 			}
-			p.progressWriter.EventUpdatedRepo(localDir)
+			p.progressWriter.EventCheckoutExtras(c.SubmodulesUpdated, c.LfsPulled)
+			if c.DivergedSkipped {
+				historyEntry.Action = historyActionDiverged
+				p.progressWriter.EventDivergedRepo(localDir)
+				return nil
+			}
+			p.progressWriter.EventUpdatedRepo(localDir, strategy)
 		} else {
+			historyEntry.Action = historyActionSkip
 			p.progressWriter.EventSkippedRepo(localDir)
 		}
 	} else {
 		if p.cloneRepos {
+			historyEntry.Action = historyActionClone
 			err := c.doClone(gitUrl.String(), "")
 			if err != nil {
 				p.progressWriter.EventSyncedRepoError(localDir)
 				return fmt.Errorf("error cloning: %w", err)
 			}
+			p.progressWriter.EventCheckoutExtras(c.SubmodulesUpdated, c.LfsPulled)
 			p.progressWriter.EventClonedRepo(localDir)
 		} else {
+			historyEntry.Action = historyActionSkip
 			p.progressWriter.EventSkippedRepo(localDir)
 		}
 	}
@@ -438,29 +728,95 @@ func (p *syncReposWorkerPool) doWork(r RemoteRepo) error {
 	return nil
 }
 
+// pruneLocalBranches deletes merged and gone branches after an update. Failing
+// to prune doesn't fail the sync of the repo.
+func (p *syncReposWorkerPool) pruneLocalBranches(localDir string) {
+	pruned, err := pruneBranches(localDir, false, false)
+	for _, b := range pruned {
+		p.progressWriter.Info(fmt.Sprintf("Deleted %s", b))
+	}
+	if err != nil {
+		p.progressWriter.Info(fmt.Sprintf("Couldn't prune branches: %s", err))
+	}
+}
+
+// planWork records the action doWork would take on r without taking it
+func (p *syncReposWorkerPool) planWork(r RemoteRepo, gitUrl *url.URL, localDir string, localDirExists bool) {
+	a := plannedAction{Path: filepath.ToSlash(relativeToWorkspace(localDir))}
+
+	switch {
+	case r.IsArchived && !localDirExists:
+		p.progressWriter.EventIgnoredArchivedRepo(localDir)
+		return
+	case r.IsArchived && !p.archiveRepos:
+		a.Action, a.Reason = planActionSkip, "archived remotely, --no-archive"
+	case r.IsArchived:
+		archivePath := filepath.ToSlash(filepath.Join(archiveDir, a.Path))
+		a.Action, a.To = planActionArchive, archivePath
+		if dirExists(filepath.Join(getWorkspaceDir(), archivePath)) {
+			a = plannedAction{Action: planActionSkip, Path: a.Path, Reason: fmt.Sprintf("%s already exists", archivePath)}
+		} else if !p.force {
+			err := checkSafeToMove(localDir, "archiving")
+			if err != nil {
+				a = plannedAction{Action: planActionSkip, Path: a.Path, Reason: err.Error()}
+			}
+		}
+	case localDirExists && !p.updateRepos:
+		a.Action, a.Reason = planActionSkip, "--no-update"
+	case localDirExists:
+		a.Action, a.Strategy = planActionUpdate, p.strategies.For(localDir)
+		if a.Strategy == updateStrategyDefault {
+			a.Branch = r.DefaultBranch
+		}
+	case !p.cloneRepos:
+		a.Action, a.Reason = planActionSkip, "--no-clone"
+	default:
+		a.Action, a.Url = planActionClone, gitUrl.String()
+	}
+
+	p.plan.Add(a)
+	if a.Action == planActionUpdate && p.pruneBranches {
+		prunable, _ := findPrunableBranches(localDir)
+		for _, b := range prunable {
+			p.plan.Add(plannedAction{Action: planActionPruneBranch, Path: a.Path, Branch: b.Branch, Reason: b.Reason})
+		}
+	}
+	p.progressWriter.EventPlannedRepo(localDir)
+}
+
 func (p *syncReposWorkerPool) archive(localDir string) error {
+	newArchivedDir, err := archiveRepo(localDir, p.force, p.journal)
+	if err != nil {
+		return err
+	}
+
+	p.progressWriter.Info(fmt.Sprintf("Archived '%s' to '%s'", localDir, newArchivedDir))
+	return nil
+}
+
+// archiveRepo moves the repo at localDir to the same path under
+// $ORGIT_WORKSPACE/.archive, recording the move in journal. Repos with work
+// that would be lost are only archived with force.
+func archiveRepo(localDir string, force bool, journal *moveJournal) (string, error) {
 	rel, err := filepath.Rel(getWorkspaceDir(), localDir)
 	if err != nil {
-		return fmt.Errorf("couldn't get relative path for '%s': %w", localDir, err)
+		return "", fmt.Errorf("couldn't get relative path for '%s': %w", localDir, err)
 	}
 	newArchivedDir := filepath.Join(getWorkspaceDir(), archiveDir, rel)
 	if dirExists(newArchivedDir) {
-		return fmt.Errorf("can't archive '%s', dir '%s' already exists", localDir, newArchivedDir)
+		return "", fmt.Errorf("can't archive '%s', dir '%s' already exists", localDir, newArchivedDir)
 	}
 
-	parentDir := filepath.Dir(newArchivedDir)
-	if !dirExists(parentDir) {
-		err := os.MkdirAll(parentDir, 0755)
+	if !force {
+		err = checkSafeToMove(localDir, "archiving")
 		if err != nil {
-			return fmt.Errorf("couldn't create parent dir '%s': %w", parentDir, err)
+			return "", err
 		}
 	}
 
-	err = os.Rename(localDir, newArchivedDir)
+	err = journal.Move(moveKindArchive, localDir, newArchivedDir)
 	if err != nil {
-		return fmt.Errorf("couldn't move '%s' to '%s': %w", localDir, newArchivedDir, err)
+		return "", fmt.Errorf("couldn't move '%s' to '%s': %w", localDir, newArchivedDir, err)
 	}
-
-	p.progressWriter.Info(fmt.Sprintf("Archived '%s' to '%s'", localDir, newArchivedDir))
-	return nil
+	return newArchivedDir, nil
 }
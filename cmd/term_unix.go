@@ -0,0 +1,54 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+func isTerminal(f *os.File) bool {
+	_, err := unix.IoctlGetWinsize(int(f.Fd()), unix.TIOCGWINSZ)
+	return err == nil
+}
+
+func terminalSize(f *os.File) (cols, rows int, err error) {
+	ws, err := unix.IoctlGetWinsize(int(f.Fd()), unix.TIOCGWINSZ)
+	if err != nil {
+		return 0, 0, fmt.Errorf("couldn't get terminal size: %w", err)
+	}
+	return int(ws.Col), int(ws.Row), nil
+}
+
+func stty(in *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = in
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("stty %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// makeRaw switches the terminal of in to raw mode, so keys are read as
+// they're pressed without being echoed, and returns a function that restores
+// the previous mode. stty is used as the termios ioctls differ between
+// platforms.
+func makeRaw(in, out *os.File) (func() error, error) {
+	saved, err := stty(in, "-g")
+	if err != nil {
+		return nil, err
+	}
+	_, err = stty(in, "raw", "-echo")
+	if err != nil {
+		return nil, err
+	}
+	return func() error {
+		_, err := stty(in, saved)
+		return err
+	}, nil
+}
@@ -0,0 +1,59 @@
+//go:build windows
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+func isTerminal(f *os.File) bool {
+	var mode uint32
+	return windows.GetConsoleMode(windows.Handle(f.Fd()), &mode) == nil
+}
+
+func terminalSize(f *os.File) (cols, rows int, err error) {
+	var info windows.ConsoleScreenBufferInfo
+	err = windows.GetConsoleScreenBufferInfo(windows.Handle(f.Fd()), &info)
+	if err != nil {
+		return 0, 0, fmt.Errorf("couldn't get console size: %w", err)
+	}
+	return int(info.Window.Right - info.Window.Left + 1), int(info.Window.Bottom - info.Window.Top + 1), nil
+}
+
+// makeRaw switches the console of in to read keys as they're pressed without
+// echoing them, as virtual terminal sequences, and out to interpret ANSI
+// escape sequences. It returns a function that restores the previous modes.
+func makeRaw(in, out *os.File) (func() error, error) {
+	inHandle, outHandle := windows.Handle(in.Fd()), windows.Handle(out.Fd())
+	var inMode, outMode uint32
+	err := windows.GetConsoleMode(inHandle, &inMode)
+	if err != nil {
+		return nil, fmt.Errorf("GetConsoleMode: %w", err)
+	}
+	err = windows.GetConsoleMode(outHandle, &outMode)
+	if err != nil {
+		return nil, fmt.Errorf("GetConsoleMode: %w", err)
+	}
+
+	raw := inMode&^(windows.ENABLE_ECHO_INPUT|windows.ENABLE_LINE_INPUT|windows.ENABLE_PROCESSED_INPUT) | windows.ENABLE_VIRTUAL_TERMINAL_INPUT
+	err = windows.SetConsoleMode(inHandle, raw)
+	if err != nil {
+		return nil, fmt.Errorf("SetConsoleMode: %w", err)
+	}
+	err = windows.SetConsoleMode(outHandle, outMode|windows.ENABLE_PROCESSED_OUTPUT|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING)
+	if err != nil {
+		_ = windows.SetConsoleMode(inHandle, inMode)
+		return nil, fmt.Errorf("SetConsoleMode: %w", err)
+	}
+
+	return func() error {
+		err := windows.SetConsoleMode(inHandle, inMode)
+		if err == nil {
+			err = windows.SetConsoleMode(outHandle, outMode)
+		}
+		return err
+	}, nil
+}
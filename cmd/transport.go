@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+const (
+	transportHttps = "https"
+	transportSsh   = "ssh"
+)
+
+const defaultSshUser = "git"
+
+// transport is how to connect to the remotes of a host or org
+type transport struct {
+	Protocol string
+	User     string
+	Port     string
+}
+
+// Url returns the URL of repo using the transport
+func (t transport) Url(repo RepoName) *url.URL {
+	u := &url.URL{
+		Scheme: t.Protocol,
+		Host:   repo.Host,
+		Path:   "/" + repo.Path + ".git",
+	}
+	if t.Port != "" {
+		u.Host = fmt.Sprintf("%s:%s", repo.Host, t.Port)
+	}
+	user := t.User
+	if user == "" && t.Protocol == transportSsh {
+		user = defaultSshUser
+	}
+	if user != "" {
+		u.User = url.User(user)
+	}
+	return u
+}
+
+// sameTransport returns true if a and b use the same protocol, user and port
+func sameTransport(a, b *url.URL) bool {
+	normalise := func(u *url.URL) (string, string, string) {
+		scheme, port := u.Scheme, u.Port()
+		if scheme == "git+ssh" {
+			scheme = transportSsh
+		}
+		if (scheme == transportSsh && port == "22") || (scheme == transportHttps && port == "443") {
+			port = ""
+		}
+		return scheme, u.User.Username(), port
+	}
+	aScheme, aUser, aPort := normalise(a)
+	bScheme, bUser, bPort := normalise(b)
+	return aScheme == bScheme && aUser == bUser && aPort == bPort
+}
+
+// transportSelector chooses the transport for each repo from the most
+// specific host or org in the workspace config, e.g.
+//
+//	[transport "github.com"]
+//		protocol = ssh
+//	[transport "gitlab.example.com/group"]
+//		protocol = ssh
+//		user = gitlab
+//		port = 2222
+type transportSelector struct {
+	protocols map[string]string
+	users     map[string]string
+	ports     map[string]string
+}
+
+func newTransportSelector() (transportSelector, error) {
+	protocols, err := readSubsectionConfig("transport", "protocol")
+	if err != nil {
+		return transportSelector{}, err
+	}
+	users, err := readSubsectionConfig("transport", "user")
+	if err != nil {
+		return transportSelector{}, err
+	}
+	ports, err := readSubsectionConfig("transport", "port")
+	if err != nil {
+		return transportSelector{}, err
+	}
+	s := transportSelector{protocols: protocols, users: users, ports: ports}
+
+	for target, protocol := range s.protocols {
+		if protocol != transportHttps && protocol != transportSsh {
+			return transportSelector{}, fmt.Errorf("%s: transport '%s': unknown protocol '%s', must be https or ssh", workspaceConfigFile, target, protocol)
+		}
+	}
+	for target, port := range s.ports {
+		if _, err := strconv.Atoi(port); err != nil {
+			return transportSelector{}, fmt.Errorf("%s: transport '%s': invalid port '%s'", workspaceConfigFile, target, port)
+		}
+	}
+	return s, nil
+}
+
+// For returns the configured transport for repo, or false if there isn't one.
+// A user or port is only used if it's set on the same target as the protocol
+// or a more specific one, so a host's ssh port isn't used for an org that's
+// switched to https.
+func (s transportSelector) For(repo RepoName) (transport, bool) {
+	protocolTarget, protocol, ok := matchTargetWithPath(s.protocols, repo.String())
+	if !ok {
+		return transport{}, false
+	}
+	withProtocol := func(targets map[string]string) string {
+		target, value, ok := matchTargetWithPath(targets, repo.String())
+		if !ok || len(target) < len(protocolTarget) {
+			return ""
+		}
+		return value
+	}
+	return transport{Protocol: protocol, User: withProtocol(s.users), Port: withProtocol(s.ports)}, true
+}
+
+// Apply returns gitUrl using the configured transport for its repo, and
+// whether a transport was configured
+func (s transportSelector) Apply(gitUrl *url.URL) (*url.URL, bool) {
+	repo := repoNameFromGitUrl(gitUrl)
+	t, ok := s.For(repo)
+	if !ok {
+		return gitUrl, false
+	}
+	return t.Url(repo), true
+}
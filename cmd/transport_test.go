@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTransportSelector(t *testing.T) {
+	workspace := t.TempDir()
+	t.Setenv("ORGIT_WORKSPACE", workspace)
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+	writeTestFile(t, filepath.Join(workspace, workspaceConfigFile), `
+[transport "github.com"]
+	protocol = ssh
+[transport "github.com/public"]
+	protocol = https
+[transport "gitlab.example.com/group"]
+	protocol = ssh
+	user = gitlab
+	port = 2222
+[transport "git.example.com"]
+	protocol = ssh
+	user = git
+	port = 2222
+[transport "git.example.com/public"]
+	protocol = https
+`)
+
+	s, err := newTransportSelector()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]string{
+		"https://github.com/org/repo.git":             "ssh://git@github.com/org/repo.git",
+		"https://github.com/public/repo.git":          "https://github.com/public/repo.git",
+		"git@github.com:public/repo.git":              "https://github.com/public/repo.git",
+		"https://gitlab.example.com/group/sub/x.git":  "ssh://gitlab@gitlab.example.com:2222/group/sub/x.git",
+		"https://gitlab.example.com/other/x.git":      "https://gitlab.example.com/other/x.git",
+		"https://git.example.com/org/x.git":           "ssh://git@git.example.com:2222/org/x.git",
+		"ssh://git@git.example.com:2222/public/x.git": "https://git.example.com/public/x.git",
+	}
+	for in, expected := range tests {
+		gitUrl, err := getGitUrl(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual, _ := s.Apply(gitUrl)
+		if actual.String() != expected {
+			t.Errorf("%s: expected %s, got %s", in, expected, actual)
+		}
+	}
+	if _, ok := s.For(RepoName{Host: "bitbucket.org", Path: "org/repo"}); ok {
+		t.Error("expected no transport for an unconfigured host")
+	}
+
+	writeTestFile(t, filepath.Join(workspace, workspaceConfigFile), "[transport \"github.com\"]\n\tprotocol = ftp\n")
+	if _, err = newTransportSelector(); err == nil || !strings.Contains(err.Error(), "github.com") {
+		t.Errorf("expected an error naming the misconfigured transport, got %v", err)
+	}
+	writeTestFile(t, filepath.Join(workspace, workspaceConfigFile), "[transport \"github.com\"]\n\tprotocol = ssh\n\tport = ssh\n")
+	if _, err = newTransportSelector(); err == nil {
+		t.Error("expected an error for an invalid port")
+	}
+}
+
+func TestSameTransport(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"ssh://git@github.com/org/a.git", "git@github.com:org/b.git", true},
+		{"git+ssh://git@github.com/org/a", "ssh://git@github.com:22/org/a.git", true},
+		{"https://github.com/org/a", "https://github.com:443/org/a.git", true},
+		{"https://github.com/org/a", "ssh://git@github.com/org/a.git", false},
+		{"ssh://git@github.com/org/a", "ssh://me@github.com/org/a.git", false},
+		{"ssh://git@github.com/org/a", "ssh://git@github.com:2222/org/a.git", false},
+	}
+	for _, tt := range tests {
+		a, err := getRemoteGitUrl(tt.a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := getRemoteGitUrl(tt.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := sameTransport(a, b); actual != tt.expected {
+			t.Errorf("sameTransport(%s, %s): expected %v, got %v", tt.a, tt.b, tt.expected, actual)
+		}
+	}
+}
+
+func TestFixRemoteConfigWithTransport(t *testing.T) {
+	_, local := newTestRepoWithRemote(t)
+	c := getCmdContext{
+		Stdout:      io.Discard,
+		Stderr:      io.Discard,
+		CmdEchoFunc: func(cmd, dir string) {},
+		WorkingDir:  local,
+		Transport:   true,
+	}
+	originUrl := func() string {
+		out, err := c.doExec("git config --get remote.origin.url")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(out)
+	}
+
+	gitInTest(t, local, "remote", "set-url", "origin", "https://github.com/org/repo.git")
+	sshUrl, _ := url.Parse("ssh://git@github.com/org/repo.git")
+	if err := c.fixRemoteConfig(sshUrl); err != nil {
+		t.Fatal(err)
+	}
+	if actual := originUrl(); actual != "ssh://git@github.com/org/repo.git" {
+		t.Errorf("expected origin to use the configured transport, got %s", actual)
+	}
+
+	gitInTest(t, local, "remote", "set-url", "origin", "git@github.com:org/repo.git")
+	if err := c.fixRemoteConfig(sshUrl); err != nil {
+		t.Fatal(err)
+	}
+	if actual := originUrl(); actual != "git@github.com:org/repo.git" {
+		t.Errorf("expected an scp-like origin with the same transport to be kept, got %s", actual)
+	}
+}
@@ -0,0 +1,346 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const trashLogFile = "trash.jsonl"
+
+const (
+	trashReasonNotFound    = "not found on remote"
+	trashReasonNotAGitRepo = "not a git repository"
+)
+
+// trashRecord records why and when a path was moved to the trash
+type trashRecord struct {
+	Path      string    `json:"path"` // relative to the workspace
+	TrashedAt time.Time `json:"trashed_at"`
+	Reason    string    `json:"reason"`
+}
+
+var trashLogMutex sync.Mutex
+
+func trashLogPath() string {
+	return filepath.Join(getStateDir(), trashLogFile)
+}
+
+func appendTrashRecord(r trashRecord) error {
+	trashLogMutex.Lock()
+	defer trashLogMutex.Unlock()
+
+	err := os.MkdirAll(getStateDir(), 0755)
+	if err != nil {
+		return fmt.Errorf("couldn't create state dir: %w", err)
+	}
+	f, err := os.OpenFile(trashLogPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("couldn't open trash log: %w", err)
+	}
+	defer f.Close()
+
+	err = json.NewEncoder(f).Encode(r)
+	if err != nil {
+		return fmt.Errorf("couldn't write trash log: %w", err)
+	}
+	return nil
+}
+
+func readTrashRecords() (map[string]trashRecord, error) {
+	records := map[string]trashRecord{}
+	f, err := os.Open(trashLogPath())
+	if os.IsNotExist(err) {
+		return records, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open trash log: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r trashRecord
+		if json.Unmarshal(scanner.Bytes(), &r) == nil {
+			records[r.Path] = r // later records replace earlier ones
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("couldn't read trash log: %w", err)
+	}
+	return records, nil
+}
+
+func writeTrashRecords(records []trashRecord) error {
+	trashLogMutex.Lock()
+	defer trashLogMutex.Unlock()
+
+	buf := bytes.Buffer{}
+	enc := json.NewEncoder(&buf)
+	for _, r := range records {
+		err := enc.Encode(r)
+		if err != nil {
+			return fmt.Errorf("couldn't encode trash log: %w", err)
+		}
+	}
+
+	// written to a temporary file first so the trash log is never left partial
+	tmp := trashLogPath() + ".tmp"
+	err := os.WriteFile(tmp, buf.Bytes(), 0644)
+	if err != nil {
+		return fmt.Errorf("couldn't write trash log: %w", err)
+	}
+	return os.Rename(tmp, trashLogPath())
+}
+
+// listTrash returns the paths currently in the trash. Paths trashed before
+// the trash log existed are found by looking for git repos, using the
+// modification time as the trashed date.
+func listTrash() ([]trashRecord, error) {
+	records, err := readTrashRecords()
+	if err != nil {
+		return nil, err
+	}
+
+	trashed := []trashRecord{}
+	for _, r := range records {
+		if _, err := os.Lstat(filepath.Join(getTrashDir(), r.Path)); err == nil {
+			trashed = append(trashed, r)
+		}
+	}
+
+	if dirExists(getTrashDir()) {
+		forEachGitDirIn(getTrashDir(), func(relativeDir string) {
+			relativeDir = filepath.ToSlash(relativeDir)
+			if _, ok := records[relativeDir]; ok {
+				return
+			}
+			r := trashRecord{Path: relativeDir, Reason: "unknown"}
+			if info, err := os.Stat(filepath.Join(getTrashDir(), relativeDir)); err == nil {
+				r.TrashedAt = info.ModTime()
+			}
+			trashed = append(trashed, r)
+		})
+	}
+
+	slices.SortFunc(trashed, func(a, b trashRecord) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return trashed, nil
+}
+
+// removeEmptyParents removes empty directories between dir and stopDir
+func removeEmptyParents(dir, stopDir string) {
+	for dir != stopDir && strings.HasPrefix(dir, stopDir+string(filepath.Separator)) {
+		if os.Remove(dir) != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// emptyTrash permanently deletes trashed paths older than olderThan, or all
+// trashed paths if olderThan is zero
+func emptyTrash(olderThan time.Duration, now time.Time) ([]trashRecord, error) {
+	trashed, err := listTrash()
+	if err != nil {
+		return nil, err
+	}
+
+	removed := []trashRecord{}
+	kept := []trashRecord{}
+	for _, r := range trashed {
+		if olderThan > 0 && now.Sub(r.TrashedAt) < olderThan {
+			kept = append(kept, r)
+			continue
+		}
+		absPath := filepath.Join(getTrashDir(), r.Path)
+		err := os.RemoveAll(absPath)
+		if err != nil {
+			return removed, fmt.Errorf("couldn't remove '%s': %w", absPath, err)
+		}
+		removeEmptyParents(filepath.Dir(absPath), getTrashDir())
+		removed = append(removed, r)
+	}
+
+	return removed, writeTrashRecords(kept)
+}
+
+// findRestorable locates pathArg in the archive or trash, returning the
+// archive or trash dir it was found in and its location in the workspace
+func findRestorable(pathArg string) (fromBaseDir, relPath string, err error) {
+	relPath = pathArg
+	if filepath.IsAbs(pathArg) {
+		relPath, err = filepath.Rel(getWorkspaceDir(), pathArg)
+		if err != nil || strings.HasPrefix(relPath, "..") {
+			return "", "", fmt.Errorf("'%s' is not in the workspace", pathArg)
+		}
+	}
+	relPath = filepath.Clean(relPath)
+
+	baseDirs := []string{archiveDir, trashDir}
+	for _, d := range baseDirs {
+		if rest, ok := strings.CutPrefix(relPath, d+string(filepath.Separator)); ok {
+			baseDirs = []string{d}
+			relPath = rest
+			break
+		}
+	}
+
+	found := []string{}
+	for _, d := range baseDirs {
+		if _, err := os.Lstat(filepath.Join(getWorkspaceDir(), d, relPath)); err == nil {
+			found = append(found, d)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", "", fmt.Errorf("'%s' is not in %s or %s", relPath, archiveDir, trashDir)
+	case 1:
+		return filepath.Join(getWorkspaceDir(), found[0]), relPath, nil
+	default:
+		return "", "", fmt.Errorf("'%s' is in both %s and %s, specify which to restore, e.g. '%s'", relPath, archiveDir, trashDir, filepath.Join(trashDir, relPath))
+	}
+}
+
+// restore moves an archived or trashed path back into the workspace
+func restore(pathArg string) (from, to string, err error) {
+	fromBaseDir, relPath, err := findRestorable(pathArg)
+	if err != nil {
+		return "", "", err
+	}
+	from = filepath.Join(fromBaseDir, relPath)
+	to = filepath.Join(getWorkspaceDir(), relPath)
+	if _, err := os.Lstat(to); err == nil {
+		return "", "", fmt.Errorf("can't restore '%s', '%s' already exists", from, to)
+	}
+
+	err = osMove(from, to)
+	if err != nil {
+		return "", "", fmt.Errorf("couldn't restore '%s' to '%s': %w", from, to, err)
+	}
+	removeEmptyParents(filepath.Dir(from), fromBaseDir)
+
+	if fromBaseDir == getTrashDir() {
+		err = forgetTrashRecord(relPath)
+	}
+	return from, to, err
+}
+
+func forgetTrashRecord(relPath string) error {
+	records, err := readTrashRecords()
+	if err != nil {
+		return err
+	}
+	if _, ok := records[filepath.ToSlash(relPath)]; !ok {
+		return nil
+	}
+	delete(records, filepath.ToSlash(relPath))
+
+	remaining := []trashRecord{}
+	for _, r := range records {
+		remaining = append(remaining, r)
+	}
+	return writeTrashRecords(remaining)
+}
+
+func init() {
+	var cmdRestore = &cobra.Command{
+		Use:   "restore PATH",
+		Short: "Restore an archived or trashed repository",
+		Long: `Restore an archived or trashed repository by moving it from $ORGIT_WORKSPACE/.archive
+or $ORGIT_WORKSPACE/.trash back to its location in the workspace.
+
+PATH is the path of the repository relative to the workspace, e.g. github.com/my-org/my-repo,
+optionally prefixed with .archive or .trash.
+`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			lock, err := acquireWorkspaceLock(false, func(msg string) { cmd.PrintErrln(msg) })
+			if err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+			defer lock.Release()
+
+			from, to, err := restore(args[0])
+			if err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+			fmt.Printf("Restored '%s' to '%s'\n", from, to)
+		},
+	}
+
+	var cmdTrash = &cobra.Command{
+		Use:   "trash",
+		Short: "Manage repositories moved to the trash by sync --tidy",
+	}
+
+	var cmdTrashList = &cobra.Command{
+		Use:   "list",
+		Short: "List trashed paths with when and why they were trashed",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			trashed, err := listTrash()
+			if err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "PATH\tTRASHED\tREASON")
+			for _, r := range trashed {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", r.Path, r.TrashedAt.Local().Format(time.DateTime), r.Reason)
+			}
+			w.Flush()
+		},
+	}
+
+	var olderThan string
+	var cmdTrashEmpty = &cobra.Command{
+		Use:   "empty",
+		Short: "Permanently delete trashed paths",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			var age time.Duration
+			if olderThan != "" {
+				var err error
+				age, err = parseAge(olderThan)
+				if err != nil {
+					cmd.PrintErrln(err)
+					os.Exit(1)
+				}
+			}
+
+			lock, err := acquireWorkspaceLock(false, func(msg string) { cmd.PrintErrln(msg) })
+			if err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+			defer lock.Release()
+
+			removed, err := emptyTrash(age, time.Now())
+			for _, r := range removed {
+				fmt.Printf("Deleted '%s'\n", filepath.Join(getTrashDir(), r.Path))
+			}
+			if err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+		},
+	}
+	cmdTrashEmpty.Flags().StringVar(&olderThan, "older-than", "", "Only delete paths trashed longer ago than this duration, e.g. 30d")
+
+	cmdTrash.AddCommand(cmdTrashList, cmdTrashEmpty)
+	rootCmd.AddCommand(cmdRestore, cmdTrash)
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func mkdirs(t *testing.T, dirs ...string) {
+	t.Helper()
+	for _, d := range dirs {
+		err := os.MkdirAll(d, 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRestore(t *testing.T) {
+	workspace := t.TempDir()
+	t.Setenv("ORGIT_WORKSPACE", workspace)
+
+	mkdirs(t,
+		filepath.Join(workspace, archiveDir, "github.com/org/archived/.git"),
+		filepath.Join(workspace, trashDir, "github.com/org/trashed/.git"),
+		filepath.Join(workspace, trashDir, "github.com/org/both/.git"),
+		filepath.Join(workspace, archiveDir, "github.com/org/both/.git"),
+		filepath.Join(workspace, trashDir, "github.com/org/conflict/.git"),
+		filepath.Join(workspace, "github.com/org/conflict/.git"),
+	)
+	err := appendTrashRecord(trashRecord{Path: "github.com/org/trashed", TrashedAt: time.Now(), Reason: trashReasonNotFound})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		arg         string
+		expectedTo  string
+		expectError bool
+	}{
+		{"github.com/org/archived", "github.com/org/archived", false},
+		{filepath.Join(workspace, trashDir, "github.com/org/trashed"), "github.com/org/trashed", false},
+		{"github.com/org/both", "", true},
+		{".archive/github.com/org/both", "github.com/org/both", false},
+		{"github.com/org/conflict", "", true},
+		{"github.com/org/missing", "", true},
+	}
+
+	for _, tt := range tests {
+		_, to, err := restore(tt.arg)
+		if tt.expectError {
+			if err == nil {
+				t.Errorf("restore(%s): expected an error but got none", tt.arg)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("restore(%s): did not expect an error but got %v", tt.arg, err)
+			continue
+		}
+		if to != filepath.Join(workspace, tt.expectedTo) || !isGitRepo(to) {
+			t.Errorf("restore(%s): expected repo at %s, got %s", tt.arg, tt.expectedTo, to)
+		}
+	}
+
+	if dirExists(filepath.Join(workspace, archiveDir, "github.com")) {
+		t.Errorf("Expected empty archive dirs to be removed")
+	}
+	records, err := readTrashRecords()
+	if err != nil || len(records) != 0 {
+		t.Errorf("Expected restored repo to be removed from the trash log, got %v %v", records, err)
+	}
+}
+
+func TestEmptyTrash(t *testing.T) {
+	workspace := t.TempDir()
+	t.Setenv("ORGIT_WORKSPACE", workspace)
+	now := time.Now()
+
+	mkdirs(t,
+		filepath.Join(workspace, trashDir, "github.com/org/old/.git"),
+		filepath.Join(workspace, trashDir, "github.com/org/new/.git"),
+		filepath.Join(workspace, trashDir, "github.com/other/untracked/.git"),
+	)
+	for _, r := range []trashRecord{
+		{Path: "github.com/org/old", TrashedAt: now.Add(-40 * 24 * time.Hour), Reason: trashReasonNotFound},
+		{Path: "github.com/org/new", TrashedAt: now.Add(-time.Hour), Reason: trashReasonNotFound},
+		{Path: "github.com/org/gone", TrashedAt: now.Add(-40 * 24 * time.Hour), Reason: trashReasonNotFound},
+	} {
+		if err := appendTrashRecord(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	trashed, err := listTrash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trashed) != 3 || trashed[2].Path != "github.com/other/untracked" || trashed[2].Reason != "unknown" {
+		t.Fatalf("Unexpected trash listing %v", trashed)
+	}
+
+	removed, err := emptyTrash(30*24*time.Hour, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0].Path != "github.com/org/old" {
+		t.Errorf("Expected only the old repo to be removed, got %v", removed)
+	}
+	if !dirExists(filepath.Join(workspace, trashDir, "github.com/org/new")) {
+		t.Errorf("Expected the new repo to be kept")
+	}
+
+	_, err = emptyTrash(0, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dirExists(filepath.Join(workspace, trashDir, "github.com")) {
+		t.Errorf("Expected the trash to be empty")
+	}
+}
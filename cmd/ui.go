@@ -0,0 +1,688 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+	"github.com/sourcegraph/conc/pool"
+	"github.com/spf13/cobra"
+)
+
+const (
+	uiRepoLocal    = "local"
+	uiRepoArchived = "archived"
+	uiRepoRemote   = "remote"
+)
+
+const (
+	uiActionGet     = 'g'
+	uiActionUpdate  = 'u'
+	uiActionArchive = 'a'
+	uiActionRestore = 'r'
+	uiActionShell   = 's'
+)
+
+var uiActionNames = map[rune]string{
+	uiActionGet:     "get",
+	uiActionUpdate:  "update",
+	uiActionArchive: "archive",
+	uiActionRestore: "restore",
+	uiActionShell:   "open shell",
+}
+
+const (
+	ansiEnterAltScreen = "\033[?1049h\033[H"
+	ansiLeaveAltScreen = "\033[?1049l"
+	ansiHideCursor     = "\033[?25l"
+	ansiShowCursor     = "\033[?25h"
+	ansiCursorHome     = "\033[H"
+	ansiClearToEOL     = "\033[K"
+	ansiReverse        = "\033[7m"
+	ansiReset          = "\033[0m"
+)
+
+// uiRepo is a repo listed by 'orgit ui'
+type uiRepo struct {
+	Name   string // relative to the workspace, e.g. github.com/org/repo or .archive/github.com/org/repo
+	Kind   string // uiRepoLocal, uiRepoArchived or uiRepoRemote
+	Dir    string // empty for remote repos
+	Remote RemoteRepo
+	Status *repoStatus // nil until loaded, and for remote repos
+}
+
+// actions returns the keys of the actions that apply to the repo
+func (r uiRepo) actions() []rune {
+	switch r.Kind {
+	case uiRepoRemote:
+		return []rune{uiActionGet}
+	case uiRepoArchived:
+		return []rune{uiActionRestore, uiActionShell}
+	default:
+		return []rune{uiActionUpdate, uiActionArchive, uiActionShell}
+	}
+}
+
+// loadUiRepos lists the repos in the workspace, then the archived repos, then
+// the repos in the listings cached by 'orgit sync' that aren't cloned
+func loadUiRepos() []uiRepo {
+	workspace := getWorkspaceDir()
+	repos := []uiRepo{}
+	cloned := map[string]bool{}
+	for _, name := range localRepoNames() {
+		cloned[name] = true
+		repos = append(repos, uiRepo{Name: name, Kind: uiRepoLocal, Dir: filepath.Join(workspace, filepath.FromSlash(name))})
+	}
+
+	archived := filepath.Join(workspace, archiveDir)
+	if dirExists(archived) {
+		names := []string{}
+		forEachGitDirIn(archived, func(relativeDir string) {
+			names = append(names, filepath.ToSlash(relativeDir))
+		})
+		slices.Sort(names)
+		for _, name := range names {
+			cloned[name] = true
+			repos = append(repos, uiRepo{Name: archiveDir + "/" + name, Kind: uiRepoArchived, Dir: filepath.Join(archived, filepath.FromSlash(name))})
+		}
+	}
+
+	for _, r := range readCachedRemoteRepos() {
+		if !cloned[r.RepoName.String()] {
+			repos = append(repos, uiRepo{Name: r.RepoName.String(), Kind: uiRepoRemote, Remote: r})
+		}
+	}
+	return repos
+}
+
+// uiKey is a key pressed in 'orgit ui', either a printable rune or a named
+// special key
+type uiKey struct {
+	Rune rune
+	Name string
+}
+
+var uiCsiKeys = map[string]string{
+	"A": "up", "B": "down", "H": "home", "F": "end",
+	"5~": "pgup", "6~": "pgdown", "1~": "home", "4~": "end",
+}
+
+var uiControlKeys = map[byte]string{
+	0x03: "ctrl-c", 0x04: "ctrl-d", 0x0a: "enter", 0x0d: "enter",
+	0x08: "backspace", 0x7f: "backspace", 0x15: "ctrl-u",
+	0x0e: "down", 0x10: "up",
+}
+
+// parseKeys parses the bytes read from a terminal in raw mode into keys. An
+// escape on its own is the escape key, as escape sequences arrive together.
+func parseKeys(b []byte) []uiKey {
+	keys := []uiKey{}
+	for i := 0; i < len(b); {
+		switch {
+		case b[i] == 0x1b && i+1 < len(b) && (b[i+1] == '[' || b[i+1] == 'O'):
+			// a CSI or SS3 sequence ends with a byte in the range 0x40-0x7e
+			j := i + 2
+			for j < len(b) && (b[j] < 0x40 || b[j] > 0x7e) {
+				j++
+			}
+			if name, ok := uiCsiKeys[string(b[i+2:min(j+1, len(b))])]; ok {
+				keys = append(keys, uiKey{Name: name})
+			}
+			i = j + 1
+		case b[i] == 0x1b:
+			keys = append(keys, uiKey{Name: "esc"})
+			i++
+		case uiControlKeys[b[i]] != "":
+			keys = append(keys, uiKey{Name: uiControlKeys[b[i]]})
+			i++
+		default:
+			r, size := utf8.DecodeRune(b[i:])
+			if unicode.IsPrint(r) {
+				keys = append(keys, uiKey{Rune: r})
+			}
+			i += size
+		}
+	}
+	return keys
+}
+
+// uiState is the state of 'orgit ui', separate from the terminal
+type uiState struct {
+	repos    []uiRepo
+	query    string
+	matches  []int // indexes of the repos matching query, best first
+	cursor   int   // index into matches
+	offset   int   // the first match shown
+	pageSize int
+	menu     bool // choosing an action for the selected repo
+	message  string
+}
+
+func newUiState(repos []uiRepo) *uiState {
+	s := &uiState{repos: repos, pageSize: 10}
+	s.filter()
+	return s
+}
+
+// filter matches the repos against the query, selecting the best match
+func (s *uiState) filter() {
+	if s.query == "" {
+		s.matches = make([]int, len(s.repos))
+		for i := range s.repos {
+			s.matches[i] = i
+		}
+	} else {
+		names := make([]string, len(s.repos))
+		for i, r := range s.repos {
+			names[i] = r.Name
+		}
+		s.matches = fuzzyRank(s.query, names)
+	}
+
+	s.cursor, s.offset = 0, 0
+}
+
+func (s *uiState) selected() (uiRepo, bool) {
+	if s.cursor < 0 || s.cursor >= len(s.matches) {
+		return uiRepo{}, false
+	}
+	return s.repos[s.matches[s.cursor]], true
+}
+
+func (s *uiState) move(delta int) {
+	s.cursor = max(0, min(len(s.matches)-1, s.cursor+delta))
+}
+
+// setRepos replaces the repos, e.g. after an action moved one, keeping the
+// statuses already loaded and the selected repo if it's still there
+func (s *uiState) setRepos(repos []uiRepo) {
+	selected, hadSelection := s.selected()
+	statuses := map[string]*repoStatus{}
+	for _, r := range s.repos {
+		if r.Status != nil {
+			statuses[r.Dir] = r.Status
+		}
+	}
+	for i := range repos {
+		if repos[i].Dir != "" {
+			repos[i].Status = statuses[repos[i].Dir]
+		}
+	}
+	s.repos = repos
+	s.filter()
+	if hadSelection {
+		for i, m := range s.matches {
+			if s.repos[m].Name == selected.Name {
+				s.cursor = i
+			}
+		}
+	}
+}
+
+func (s *uiState) setStatus(dir string, status repoStatus) {
+	for i := range s.repos {
+		if s.repos[i].Dir == dir {
+			s.repos[i].Status = &status
+		}
+	}
+}
+
+// handleKey updates the state for a key, returning an action to run on the
+// selected repo, or quit
+func (s *uiState) handleKey(k uiKey) (action rune, quit bool) {
+	if s.menu {
+		s.menu = false
+		r, ok := s.selected()
+		if ok && slices.Contains(r.actions(), k.Rune) {
+			return k.Rune, false
+		}
+		return 0, false
+	}
+
+	s.message = ""
+	switch k.Name {
+	case "ctrl-c", "ctrl-d":
+		return 0, true
+	case "esc":
+		if s.query == "" {
+			return 0, true
+		}
+		s.query = ""
+		s.filter()
+	case "enter":
+		_, s.menu = s.selected()
+	case "up":
+		s.move(-1)
+	case "down":
+		s.move(1)
+	case "pgup":
+		s.move(-s.pageSize)
+	case "pgdown":
+		s.move(s.pageSize)
+	case "home":
+		s.move(-len(s.matches))
+	case "end":
+		s.move(len(s.matches))
+	case "backspace":
+		if s.query != "" {
+			_, size := utf8.DecodeLastRuneInString(s.query)
+			s.query = s.query[:len(s.query)-size]
+			s.filter()
+		}
+	case "ctrl-u":
+		s.query = ""
+		s.filter()
+	case "":
+		s.query += string(k.Rune)
+		s.filter()
+	}
+	return 0, false
+}
+
+// fitWidth pads or truncates s to width runes
+func fitWidth(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	r := []rune(s)
+	if len(r) > width {
+		if width == 1 {
+			return "…"
+		}
+		return string(r[:width-1]) + "…"
+	}
+	return s + strings.Repeat(" ", width-len(r))
+}
+
+// statusColumns returns the branch, changes and ahead/behind columns of r
+func (r uiRepo) statusColumns() (string, string, string) {
+	s := r.Status
+	switch {
+	case r.Kind == uiRepoRemote && r.Remote.IsArchived:
+		return "(archived) " + r.Remote.Description, "", ""
+	case r.Kind == uiRepoRemote:
+		return r.Remote.Description, "", ""
+	case s == nil:
+		return "…", "", ""
+	case s.Error != "":
+		return "error: " + s.Error, "", ""
+	}
+
+	branch := s.Branch
+	if s.Detached {
+		branch = "(detached)"
+	}
+	changes := ""
+	if s.IsDirty() {
+		changes = "dirty"
+	}
+	aheadBehind := "-"
+	if s.Upstream != "" {
+		aheadBehind = fmt.Sprintf("↑%d ↓%d", s.Ahead, s.Behind)
+	}
+	return branch, changes, aheadBehind
+}
+
+// render returns the lines of the screen for a terminal of cols by rows, and
+// the line of the selected repo, or -1
+func (s *uiState) render(cols, rows int) ([]string, int) {
+	const whereWidth, branchWidth, changesWidth, aheadBehindWidth = 9, 24, 6, 11
+	listRows := max(1, rows-3)
+	s.pageSize = listRows
+	if s.cursor < s.offset {
+		s.offset = s.cursor
+	}
+	if s.cursor >= s.offset+listRows {
+		s.offset = s.cursor - listRows + 1
+	}
+
+	nameWidth := 20
+	for _, m := range s.matches {
+		nameWidth = max(nameWidth, utf8.RuneCountInString(s.repos[m].Name))
+	}
+	nameWidth = min(nameWidth, max(20, cols-2-whereWidth-branchWidth-changesWidth-aheadBehindWidth-4))
+	row := func(prefix, name, where, branch, changes, aheadBehind string) string {
+		// the branch column of remote repos has their description, which can be longer
+		if changes == "" && aheadBehind == "" {
+			return prefix + fitWidth(name, nameWidth) + " " + fitWidth(where, whereWidth) + " " + branch
+		}
+		return prefix + fitWidth(name, nameWidth) + " " + fitWidth(where, whereWidth) + " " +
+			fitWidth(branch, branchWidth) + " " + fitWidth(changes, changesWidth) + " " + aheadBehind
+	}
+
+	lines := []string{
+		fmt.Sprintf("> %s  (%d/%d)", s.query, len(s.matches), len(s.repos)),
+		row("  ", "REPO", "WHERE", "BRANCH", "STATE", "AHEAD/BEHIND"),
+	}
+	selectedLine := -1
+	for i := s.offset; i < len(s.matches) && i < s.offset+listRows; i++ {
+		r := s.repos[s.matches[i]]
+		branch, changes, aheadBehind := r.statusColumns()
+		prefix := "  "
+		if i == s.cursor {
+			prefix = "> "
+			selectedLine = len(lines)
+		}
+		lines = append(lines, row(prefix, r.Name, r.Kind, branch, changes, aheadBehind))
+	}
+	for len(lines) < listRows+2 {
+		lines = append(lines, "")
+	}
+
+	footer := "type to filter  ↑/↓ move  enter: actions  esc: quit"
+	if s.menu {
+		r, _ := s.selected()
+		actions := []string{}
+		for _, a := range r.actions() {
+			actions = append(actions, fmt.Sprintf("[%c] %s", a, uiActionNames[a]))
+		}
+		footer = fmt.Sprintf("%s: %s  any other key: cancel", r.Name, strings.Join(actions, "  "))
+	} else if s.message != "" {
+		footer = s.message
+	}
+	lines = append(lines, footer)
+
+	for i := range lines {
+		lines[i] = string([]rune(lines[i])[:min(utf8.RuneCountInString(lines[i]), cols)])
+	}
+	return lines, selectedLine
+}
+
+// uiStatusUpdate is the status of a repo, loaded in the background
+type uiStatusUpdate struct {
+	Dir    string
+	Status repoStatus
+}
+
+// loadUiStatuses gets the status of each dir concurrently, like 'orgit status'
+func loadUiStatuses(ctx context.Context, dirs []string, updates chan<- uiStatusUpdate) {
+	p := pool.New().WithMaxGoroutines(SyncWorkerPoolSize).WithContext(ctx)
+	for _, dir := range dirs {
+		p.Go(func(ctx context.Context) error {
+			s := getRepoStatus(filepath.Dir(dir), filepath.Base(dir))
+			select {
+			case updates <- uiStatusUpdate{Dir: dir, Status: s}:
+			case <-ctx.Done():
+			}
+			return nil
+		})
+	}
+	_ = p.Wait()
+}
+
+// uiGet gets or updates the repo like 'orgit get [--update] REPO'
+func uiGet(ctx context.Context, name string, update bool) error {
+	if update {
+		lock, err := acquireWorkspaceLock(false, func(msg string) { fmt.Println(msg) })
+		if err != nil {
+			return err
+		}
+		defer lock.Release()
+	}
+
+	transports, err := newTransportSelector()
+	if err != nil {
+		return err
+	}
+	opts := getOptions{update: update, transports: transports}
+	opts.strategies, err = newUpdateStrategySelector("")
+	if err != nil {
+		return err
+	}
+	requests, err := resolveGetArgs(ctx, []string{name}, transports)
+	if err != nil {
+		return err
+	}
+	for _, req := range requests {
+		req, err := opts.resolve(ctx, req)
+		if err != nil {
+			return err
+		}
+		c := opts.newGetCmdContext(req, os.Stdout, os.Stderr, func(cmd, dir string) { color.Cyan(" + %s", cmd) })
+		err = opts.get(ctx, c, req)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uiArchive archives the repo like a sync would, recording the move so that
+// 'orgit undo' can move it back
+func uiArchive(dir string) (string, error) {
+	lock, err := acquireWorkspaceLock(false, func(msg string) { fmt.Println(msg) })
+	if err != nil {
+		return "", err
+	}
+	defer lock.Release()
+
+	journal := newMoveJournal(newRunID(time.Now()), func(msg string) { fmt.Println(msg) })
+	defer journal.Close()
+	return archiveRepo(dir, false, journal)
+}
+
+// uiRestore restores the archived or trashed repo like 'orgit restore'
+func uiRestore(name string) (string, error) {
+	lock, err := acquireWorkspaceLock(false, func(msg string) { fmt.Println(msg) })
+	if err != nil {
+		return "", err
+	}
+	defer lock.Release()
+
+	_, to, err := restore(filepath.FromSlash(name))
+	return to, err
+}
+
+func uiShell(dir string) error {
+	shell := os.Getenv("SHELL")
+	if shell == "" && runtime.GOOS == "windows" {
+		shell = os.Getenv("COMSPEC")
+	}
+	if shell == "" {
+		shell = "sh"
+	}
+	fmt.Printf("Starting %s in '%s', exit to return to orgit ui\n", shell, dir)
+	cmd := exec.Command(shell)
+	cmd.Dir = dir
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+// runUiAction runs the action on r with the terminal in its normal mode,
+// returning the message to show when back in the ui
+func runUiAction(ctx context.Context, r uiRepo, action rune) string {
+	var err error
+	msg := ""
+	switch action {
+	case uiActionGet:
+		err = uiGet(ctx, r.Name, false)
+		msg = fmt.Sprintf("Got '%s'", r.Name)
+	case uiActionUpdate:
+		err = uiGet(ctx, r.Name, true)
+		msg = fmt.Sprintf("Updated '%s'", r.Name)
+	case uiActionArchive:
+		var to string
+		to, err = uiArchive(r.Dir)
+		msg = fmt.Sprintf("Archived '%s' to '%s', use 'orgit undo' to move it back", r.Name, relativeToWorkspace(to))
+	case uiActionRestore:
+		var to string
+		to, err = uiRestore(r.Name)
+		msg = fmt.Sprintf("Restored '%s' to '%s'", r.Name, relativeToWorkspace(to))
+	case uiActionShell:
+		err = uiShell(r.Dir)
+	}
+	if err != nil {
+		return cleanString(err.Error())
+	}
+	return msg
+}
+
+// runUi runs the ui until it's quit. Keys are only read from in when the ui
+// is waiting for one, so that actions can use the terminal.
+func runUi(ctx context.Context, in, out *os.File) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	state := newUiState(loadUiRepos())
+	statuses := make(chan uiStatusUpdate, SyncWorkerPoolSize)
+	loadStatuses := func(repos []uiRepo) {
+		dirs := []string{}
+		for _, r := range repos {
+			if r.Dir != "" && r.Status == nil {
+				dirs = append(dirs, r.Dir)
+			}
+		}
+		go loadUiStatuses(ctx, dirs, statuses)
+	}
+	loadStatuses(state.repos)
+
+	restoreTerminal, err := makeRaw(in, out)
+	if err != nil {
+		return err
+	}
+	enter := func() { fmt.Fprint(out, ansiEnterAltScreen+ansiHideCursor) }
+	leave := func() { fmt.Fprint(out, ansiShowCursor+ansiLeaveAltScreen) }
+	enter()
+	defer func() {
+		leave()
+		_ = restoreTerminal()
+	}()
+
+	reads := make(chan struct{})
+	keys := make(chan []uiKey)
+	defer close(reads)
+	go func() {
+		buf := make([]byte, 256)
+		for range reads {
+			n, err := in.Read(buf)
+			if err != nil {
+				close(keys)
+				return
+			}
+			keys <- parseKeys(buf[:n])
+		}
+	}()
+
+	draw := func() {
+		cols, rows, err := terminalSize(out)
+		if err != nil {
+			cols, rows = 80, 24
+		}
+		b := strings.Builder{}
+		b.WriteString(ansiCursorHome)
+		lines, selectedLine := state.render(cols, rows)
+		for i, line := range lines {
+			if i > 0 {
+				b.WriteString("\r\n")
+			}
+			if i == selectedLine {
+				line = ansiReverse + line + ansiReset
+			}
+			b.WriteString(line + ansiClearToEOL)
+		}
+		fmt.Fprint(out, b.String())
+	}
+
+	reads <- struct{}{}
+	for {
+		draw()
+		select {
+		case ks, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			for _, k := range ks {
+				action, quit := state.handleKey(k)
+				if quit {
+					return nil
+				}
+				if action == 0 {
+					continue
+				}
+
+				r, _ := state.selected()
+				leave()
+				err = restoreTerminal()
+				if err != nil {
+					return err
+				}
+				state.message = runUiAction(ctx, r, action)
+				if action != uiActionShell {
+					fmt.Fprint(out, "\nPress enter to return to orgit ui")
+					_, _ = in.Read(make([]byte, 256))
+				}
+				restoreTerminal, err = makeRaw(in, out)
+				if err != nil {
+					return err
+				}
+				enter()
+
+				// the repo may have moved, and its status changed
+				state.setRepos(loadUiRepos())
+				if r.Dir != "" {
+					state.setStatus(r.Dir, getRepoStatus(filepath.Dir(r.Dir), filepath.Base(r.Dir)))
+				}
+				loadStatuses(state.repos)
+				break
+			}
+			reads <- struct{}{}
+		case u := <-statuses:
+			state.setStatus(u.Dir, u.Status)
+			// apply whatever else has arrived before redrawing
+			for more := true; more; {
+				select {
+				case u := <-statuses:
+					state.setStatus(u.Dir, u.Status)
+				default:
+					more = false
+				}
+			}
+		}
+	}
+}
+
+func init() {
+	var cmdUi = &cobra.Command{
+		Use:   "ui",
+		Short: "Browse and act on repositories interactively",
+		Long: `Browse the repositories in the workspace interactively in the terminal.
+
+The repos in the workspace, the archived repos and the repos listed by previous
+syncs that aren't cloned are shown, with the branch, uncommitted changes and
+commits ahead and behind the upstream of each local repo.
+
+Type to filter the repos with a fuzzy match of their names, use the arrow keys
+to select a repo, and press enter to choose an action:
+  get         clone a remote repo, like 'orgit get'
+  update      update a local repo, like 'orgit get --update'
+  archive     move a local repo to $ORGIT_WORKSPACE/.archive, like sync does
+              for repos archived remotely. 'orgit undo' moves it back.
+  restore     move an archived repo back into the workspace, like 'orgit restore'
+  open shell  start $SHELL in the repo's directory
+
+Press esc to clear the filter, and again to quit.
+`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+				cmd.PrintErrln("orgit ui needs a terminal")
+				os.Exit(1)
+			}
+			err := runUi(cmd.Context(), os.Stdin, os.Stdout)
+			if err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+		},
+	}
+	rootCmd.AddCommand(cmdUi)
+}
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseKeys(t *testing.T) {
+	got := parseKeys([]byte("ab\x1b[A\x1b[B\x1b[5~\x1bOH\r\x7f\x03é\x1b"))
+	want := []uiKey{
+		{Rune: 'a'}, {Rune: 'b'}, {Name: "up"}, {Name: "down"}, {Name: "pgup"}, {Name: "home"},
+		{Name: "enter"}, {Name: "backspace"}, {Name: "ctrl-c"}, {Rune: 'é'}, {Name: "esc"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// unknown sequences are skipped whole
+	got = parseKeys([]byte("\x1b[1;5Cx"))
+	if !slices.Equal(got, []uiKey{{Rune: 'x'}}) {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestLoadUiRepos(t *testing.T) {
+	workspace := t.TempDir()
+	t.Setenv("ORGIT_WORKSPACE", workspace)
+	for _, dir := range []string{"example.org/org/api", filepath.Join(archiveDir, "example.org/org/old")} {
+		err := os.MkdirAll(filepath.Join(workspace, dir, ".git"), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	remote := RemoteRepo{RepoName: MustParseRepoName("example.org/org/new"), CloneUrl: "https://example.org/org/new.git", Description: "New"}
+	err := writeRemoteRepoListing(MustParseRepoName("example.org/org"), []RemoteRepo{
+		{RepoName: MustParseRepoName("example.org/org/api")},
+		{RepoName: MustParseRepoName("example.org/org/old"), IsArchived: true},
+		remote,
+	}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := loadUiRepos()
+	want := []uiRepo{
+		{Name: "example.org/org/api", Kind: uiRepoLocal, Dir: filepath.Join(workspace, "example.org/org/api")},
+		{Name: ".archive/example.org/org/old", Kind: uiRepoArchived, Dir: filepath.Join(workspace, archiveDir, "example.org/org/old")},
+		{Name: "example.org/org/new", Kind: uiRepoRemote, Remote: remote},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func testUiState() *uiState {
+	return newUiState([]uiRepo{
+		{Name: "github.com/org/api", Kind: uiRepoLocal, Dir: "/w/github.com/org/api"},
+		{Name: "github.com/org/web", Kind: uiRepoLocal, Dir: "/w/github.com/org/web"},
+		{Name: ".archive/github.com/org/old-api", Kind: uiRepoArchived, Dir: "/w/.archive/github.com/org/old-api"},
+		{Name: "github.com/org/new", Kind: uiRepoRemote},
+	})
+}
+
+func typeKeys(s *uiState, keys string) (rune, bool) {
+	var action rune
+	var quit bool
+	for _, k := range parseKeys([]byte(keys)) {
+		action, quit = s.handleKey(k)
+	}
+	return action, quit
+}
+
+func TestUiStateFilterAndActions(t *testing.T) {
+	s := testUiState()
+	if len(s.matches) != 4 {
+		t.Fatalf("expected all repos to match, got %v", s.matches)
+	}
+
+	typeKeys(s, "api")
+	if r, _ := s.selected(); r.Name != "github.com/org/api" || len(s.matches) != 2 {
+		t.Errorf("expected 2 matches with github.com/org/api selected, got %v selected of %v", r.Name, s.matches)
+	}
+
+	// actions are chosen from a menu, and only the ones that apply to the repo
+	typeKeys(s, "\x1b[B\r")
+	if !s.menu {
+		t.Fatal("expected the action menu")
+	}
+	if action, _ := typeKeys(s, "u"); action != 0 {
+		t.Errorf("expected update not to apply to an archived repo, got %c", action)
+	}
+	if action, _ := typeKeys(s, "\rr"); action != uiActionRestore {
+		t.Errorf("expected restore, got %c", action)
+	}
+
+	// backspace and esc change the query, then esc quits
+	typeKeys(s, "\x7f")
+	if s.query != "ap" {
+		t.Errorf("expected query 'ap', got '%s'", s.query)
+	}
+	if _, quit := typeKeys(s, "\x1b"); quit || s.query != "" || len(s.matches) != 4 {
+		t.Errorf("expected esc to clear the query, got quit %v query '%s'", quit, s.query)
+	}
+	if _, quit := typeKeys(s, "\x1b"); !quit {
+		t.Error("expected esc to quit")
+	}
+}
+
+func TestUiStateSetRepos(t *testing.T) {
+	s := testUiState()
+	s.setStatus("/w/github.com/org/web", repoStatus{Branch: "main"})
+	typeKeys(s, "\x1b[B")
+
+	s.setRepos(loadReposWithout(s.repos, "github.com/org/api"))
+	r, _ := s.selected()
+	if r.Name != "github.com/org/web" || r.Status == nil || r.Status.Branch != "main" {
+		t.Errorf("expected github.com/org/web to stay selected with its status, got %+v", r)
+	}
+}
+
+func loadReposWithout(repos []uiRepo, name string) []uiRepo {
+	result := []uiRepo{}
+	for _, r := range repos {
+		if r.Name != name {
+			r.Status = nil
+			result = append(result, r)
+		}
+	}
+	return result
+}
+
+func TestUiStateRender(t *testing.T) {
+	s := testUiState()
+	s.setStatus("/w/github.com/org/api", repoStatus{Branch: "main", Upstream: "origin/main", Ahead: 1, Behind: 2, Untracked: 1})
+	s.setStatus("/w/github.com/org/web", repoStatus{Detached: true})
+
+	lines, selected := s.render(100, 6)
+	if len(lines) != 6 {
+		t.Fatalf("expected 6 lines, got %d: %q", len(lines), lines)
+	}
+	if selected != 2 {
+		t.Errorf("expected the selected repo on line 2, got %d", selected)
+	}
+	for i, want := range []string{"(4/4)", "REPO", "main", "(detached)", "…"} {
+		if !strings.Contains(lines[i], want) {
+			t.Errorf("expected line %d to contain '%s', got '%s'", i, want, lines[i])
+		}
+	}
+	if !strings.Contains(lines[2], "dirty") || !strings.Contains(lines[2], "↑1 ↓2") {
+		t.Errorf("expected the status of github.com/org/api, got '%s'", lines[2])
+	}
+
+	// the list scrolls to keep the selected repo visible
+	typeKeys(s, "\x1b[F")
+	lines, selected = s.render(30, 6)
+	if selected != 4 || !strings.HasPrefix(lines[selected], "> github.com/org/new") {
+		t.Errorf("expected the last repo selected on the last list line, got %d: %q", selected, lines)
+	}
+	for _, line := range lines {
+		if len([]rune(line)) > 30 {
+			t.Errorf("expected lines truncated to 30 columns, got '%s'", line)
+		}
+	}
+}
+
+func TestUiRestoreTakesWorkspaceLock(t *testing.T) {
+	workspace := t.TempDir()
+	t.Setenv("ORGIT_WORKSPACE", workspace)
+	mkdirs(t, filepath.Join(workspace, archiveDir, "github.com/org/archived/.git"))
+
+	lock, err := acquireWorkspaceLock(false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uiRestore("github.com/org/archived"); !errors.Is(err, errLocked) {
+		t.Fatalf("Expected restore to fail while the workspace is locked, got %v", err)
+	}
+	if !dirExists(filepath.Join(workspace, archiveDir, "github.com/org/archived")) {
+		t.Error("Expected the repo not to be restored while the workspace is locked")
+	}
+	lock.Release()
+
+	to, err := uiRestore("github.com/org/archived")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if to != filepath.Join(workspace, "github.com/org/archived") {
+		t.Errorf("Expected the repo to be restored, got %s", to)
+	}
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// unpushedWork describes local changes in a repo that only exist locally
+// and would be lost if the repo was discarded
+type unpushedWork struct {
+	Dirty            bool
+	UnpushedBranches map[string]int // branch name to number of commits not on any remote
+	Stashes          int
+}
+
+func (w unpushedWork) IsEmpty() bool {
+	return !w.Dirty && len(w.UnpushedBranches) == 0 && w.Stashes == 0
+}
+
+func (w unpushedWork) String() string {
+	s := []string{}
+	if w.Dirty {
+		s = append(s, "uncommitted changes")
+	}
+	branches := []string{}
+	for b := range w.UnpushedBranches {
+		branches = append(branches, b)
+	}
+	slices.Sort(branches)
+	for _, b := range branches {
+		n := w.UnpushedBranches[b]
+		s = append(s, fmt.Sprintf("%d unpushed %s on %s", n, pluralise(n, "commit", "commits"), b))
+	}
+	if w.Stashes > 0 {
+		s = append(s, fmt.Sprintf("%d %s", w.Stashes, pluralise(w.Stashes, "stash", "stashes")))
+	}
+	return strings.Join(s, ", ")
+}
+
+func pluralise(n int, singular, plural string) string {
+	if n == 1 {
+		return singular
+	}
+	return plural
+}
+
+// checkUnpushedWork finds uncommitted changes, commits on local branches
+// that aren't on any remote, and stashes in the repo in dir
+func checkUnpushedWork(dir string) (unpushedWork, error) {
+	w := unpushedWork{UnpushedBranches: map[string]int{}}
+
+	var err error
+	w.Dirty, err = isDirty(dir)
+	if err != nil {
+		return w, err
+	}
+
+	branches, err := doExecQuietLines(dir, "git for-each-ref --format='%(refname:short)' refs/heads")
+	if err != nil {
+		return w, err
+	}
+	for _, b := range branches {
+		out, err := doExecQuietLines(dir, fmt.Sprintf("git rev-list --count %s --not --remotes", shellQuote("refs/heads/"+b)))
+		if err != nil {
+			return w, err
+		}
+		if len(out) == 1 {
+			if n, _ := strconv.Atoi(out[0]); n > 0 {
+				w.UnpushedBranches[b] = n
+			}
+		}
+	}
+
+	stashes, err := doExecQuietLines(dir, "git stash list")
+	if err != nil {
+		return w, err
+	}
+	w.Stashes = len(stashes)
+
+	return w, nil
+}
+
+// unpushedWorkError is returned when a repo can't be moved out of the
+// workspace without risking losing local work
+type unpushedWorkError struct {
+	dir    string
+	action string
+	work   unpushedWork
+	err    error
+}
+
+func (e *unpushedWorkError) Error() string {
+	if e.err != nil {
+		return fmt.Sprintf("not %s '%s', couldn't check for unpushed work: %s", e.action, e.dir, e.err)
+	}
+	return fmt.Sprintf("not %s '%s', it has %s. Use --force to override", e.action, e.dir, e.work)
+}
+
+func (e *unpushedWorkError) Unwrap() error {
+	return e.err
+}
+
+// checkSafeToMove returns an *unpushedWorkError if the git repo in dir has
+// work that only exists locally
+func checkSafeToMove(dir, action string) error {
+	work, err := checkUnpushedWork(dir)
+	if err != nil {
+		return &unpushedWorkError{dir: dir, action: action, err: err}
+	}
+	if !work.IsEmpty() {
+		return &unpushedWorkError{dir: dir, action: action, work: work}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func gitInTest(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %s: %s", args, err, out)
+	}
+}
+
+// newTestRepoWithRemote creates a repo cloned from a bare "remote" repo
+func newTestRepoWithRemote(t *testing.T) (remote, local string) {
+	t.Helper()
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	dir := t.TempDir()
+	remote = filepath.Join(dir, "remote.git")
+	local = filepath.Join(dir, "local")
+	gitInTest(t, dir, "init", "--quiet", "--bare", "--initial-branch=main", remote)
+	gitInTest(t, dir, "clone", "--quiet", remote, local)
+	gitInTest(t, local, "commit", "--quiet", "--allow-empty", "-m", "initial")
+	gitInTest(t, local, "push", "--quiet", "origin", "main")
+	return remote, local
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	err := os.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckUnpushedWork(t *testing.T) {
+	_, local := newTestRepoWithRemote(t)
+
+	w, err := checkUnpushedWork(local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !w.IsEmpty() {
+		t.Errorf("Expected no unpushed work, got %s", w)
+	}
+	if err := checkSafeToMove(local, "archiving"); err != nil {
+		t.Errorf("Expected repo to be safe to move, got %s", err)
+	}
+
+	gitInTest(t, local, "checkout", "--quiet", "-b", "feature")
+	gitInTest(t, local, "commit", "--quiet", "--allow-empty", "-m", "one")
+	gitInTest(t, local, "commit", "--quiet", "--allow-empty", "-m", "two")
+	gitInTest(t, local, "branch", "no-new-commits", "main")
+	writeTestFile(t, filepath.Join(local, "stashed"), "x")
+	gitInTest(t, local, "stash", "push", "--quiet", "--include-untracked")
+	writeTestFile(t, filepath.Join(local, "untracked"), "x")
+
+	w, err = checkUnpushedWork(local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "uncommitted changes, 2 unpushed commits on feature, 1 stash"
+	if w.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, w.String())
+	}
+	if err := checkSafeToMove(local, "archiving"); err == nil {
+		t.Errorf("Expected repo to be unsafe to move")
+	}
+}
@@ -0,0 +1,408 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/fatih/color"
+	"github.com/sourcegraph/conc/pool"
+	"github.com/spf13/cobra"
+)
+
+// worktreesDir holds the extra worktrees of repos in the workspace, at the
+// repo's path followed by "@" and the branch
+const worktreesDir = ".worktrees"
+
+// isWorktree returns true if dir is a linked worktree of a repo, which has a
+// .git file pointing at the repo rather than a .git directory
+func isWorktree(dir string) bool {
+	return fileExists(filepath.Join(dir, ".git"))
+}
+
+// gitPath returns the path of name in the git dir of the repo or worktree at
+// dir, e.g. index.lock. A worktree's .git is a file, so its git dir is found
+// with git.
+func gitPath(dir, name string) string {
+	return revParsePath(dir, "--git-path "+shellQuote(name), filepath.Join(".git", name))
+}
+
+// gitCommonPath returns the path of name in the git dir that the repo at dir
+// shares with its worktrees, e.g. FETCH_HEAD from fetching the repo
+func gitCommonPath(dir, name string) string {
+	return filepath.Join(revParsePath(dir, "--git-common-dir", ".git"), name)
+}
+
+// revParsePath returns the path printed by `git rev-parse arg` in dir, or
+// fallback if git fails. Relative paths are resolved against dir.
+func revParsePath(dir, arg, fallback string) string {
+	p := fallback
+	out, err := doExecQuietLines(dir, "git rev-parse "+arg)
+	if err == nil && len(out) == 1 {
+		p = out[0]
+	}
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(dir, p)
+	}
+	return p
+}
+
+// gitWorktree is a working tree of a repo, as listed by `git worktree list`
+type gitWorktree struct {
+	Path   string `json:"path"`
+	Head   string `json:"head"`
+	Branch string `json:"branch"` // empty when HEAD is detached
+	Main   bool   `json:"main"`   // the repo's own working tree
+}
+
+// getWorktreeDir returns where the worktree of repoDir for branch goes
+func getWorktreeDir(repoDir, branch string) string {
+	name := relativeToWorkspace(repoDir) + "@" + strings.ReplaceAll(branch, "/", "-")
+	return filepath.Join(getWorkspaceDir(), worktreesDir, name)
+}
+
+// parseWorktreeList parses the output of `git worktree list --porcelain`
+func parseWorktreeList(lines []string) []gitWorktree {
+	worktrees := []gitWorktree{}
+	for _, line := range lines {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "worktree":
+			worktrees = append(worktrees, gitWorktree{Path: filepath.FromSlash(value), Main: len(worktrees) == 0})
+		case "HEAD":
+			if len(worktrees) > 0 {
+				worktrees[len(worktrees)-1].Head = value
+			}
+		case "branch":
+			if len(worktrees) > 0 {
+				worktrees[len(worktrees)-1].Branch = strings.TrimPrefix(value, "refs/heads/")
+			}
+		}
+	}
+	return worktrees
+}
+
+func listWorktrees(repoDir string) ([]gitWorktree, error) {
+	lines, err := doExecQuietLines(repoDir, `git worktree list --porcelain`)
+	if err != nil {
+		return nil, fmt.Errorf("error listing worktrees of '%s': %w", repoDir, err)
+	}
+	return parseWorktreeList(lines), nil
+}
+
+// worktreeWithBranch returns the worktree of repoDir with branch checked
+// out, or false if it isn't checked out
+func worktreeWithBranch(repoDir, branch string) (gitWorktree, bool, error) {
+	worktrees, err := listWorktrees(repoDir)
+	if err != nil {
+		return gitWorktree{}, false, err
+	}
+	for _, w := range worktrees {
+		if w.Branch == branch {
+			return w, true, nil
+		}
+	}
+	return gitWorktree{}, false, nil
+}
+
+// addWorktree checks out branch in a new worktree of the repo under
+// worktreesDir. A branch that only exists on origin is created tracking it,
+// and a new branch is created from HEAD.
+func (c *getCmdContext) addWorktree(branch string) (string, error) {
+	if w, found, err := worktreeWithBranch(c.WorkingDir, branch); err != nil {
+		return "", err
+	} else if found {
+		return "", fmt.Errorf("'%s' is already checked out in '%s'", branch, w.Path)
+	}
+	dir := getWorktreeDir(c.WorkingDir, branch)
+	if _, err := os.Lstat(dir); err == nil {
+		return "", fmt.Errorf("'%s' already exists", dir)
+	}
+
+	var err error
+	switch {
+	case c.branchExists(branch):
+		err = c.echoEvalf(`git worktree add %s %s`, shellQuote(dir), shellQuote(branch))
+	case c.remoteBranchExists(branch):
+		err = c.echoEvalf(`git worktree add --track -b %s %s %s`, shellQuote(branch), shellQuote(dir), shellQuote("origin/"+branch))
+	default:
+		err = c.echoEvalf(`git worktree add -b %s %s`, shellQuote(branch), shellQuote(dir))
+	}
+	if err != nil {
+		return "", fmt.Errorf("error adding worktree for '%s': %w", branch, err)
+	}
+
+	wc := *c
+	wc.WorkingDir = dir
+	return dir, wc.updateCheckoutExtras()
+}
+
+func (c *getCmdContext) remoteBranchExists(branch string) bool {
+	_, err := c.doExec(fmt.Sprintf(`git rev-parse --quiet --verify %s`, shellQuote("refs/remotes/origin/"+branch)))
+	return err == nil
+}
+
+// mainWorktreeOf returns the repo that the worktree at dir belongs to
+func mainWorktreeOf(dir string) (string, error) {
+	commonDir, err := doExecQuietWithOutput(dir, `git rev-parse --path-format=absolute --git-common-dir`)
+	if err != nil {
+		return "", fmt.Errorf("'%s' isn't a worktree: %w", dir, err)
+	}
+	return filepath.Dir(filepath.FromSlash(commonDir)), nil
+}
+
+// removeWorktree removes the worktree at dir from its repo, along with any
+// directories left empty under worktreesDir. Worktrees with uncommitted
+// changes are only removed with force.
+func (c *getCmdContext) removeWorktree(dir string, force bool) error {
+	if !isWorktree(dir) {
+		return fmt.Errorf("'%s' isn't a worktree", dir)
+	}
+	repoDir, err := mainWorktreeOf(dir)
+	if err != nil {
+		return err
+	}
+
+	rc := *c
+	rc.WorkingDir = repoDir
+	forceFlag := ""
+	if force {
+		forceFlag = " --force"
+	}
+	err = rc.echoEvalf(`git worktree remove%s %s`, forceFlag, shellQuote(dir))
+	if err != nil {
+		return fmt.Errorf("error removing worktree '%s': %w", dir, err)
+	}
+	removeEmptyParents(filepath.Dir(dir), filepath.Join(getWorkspaceDir(), worktreesDir))
+	return nil
+}
+
+// fastForwardWorktrees brings the branches checked out in the repo's other
+// worktrees up to date with their upstreams after a fetch. Worktrees with
+// uncommitted changes, without an upstream or that can't be fast-forwarded
+// are left alone.
+func (c *getCmdContext) fastForwardWorktrees() error {
+	worktrees, err := listWorktrees(c.WorkingDir)
+	if err != nil {
+		return err
+	}
+	for _, w := range worktrees {
+		if w.Main || w.Branch == "" || !dirExists(w.Path) {
+			continue
+		}
+		wc := *c
+		wc.WorkingDir = w.Path
+		if _, err := wc.doExec(`git rev-parse --verify @{u}`); err != nil {
+			continue
+		}
+		dirty, err := isDirty(w.Path)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			c.info(fmt.Sprintf("Not updating worktree '%s', it has uncommitted changes", w.Path))
+			continue
+		}
+		d, err := wc.checkDivergence()
+		if err != nil {
+			return err
+		}
+		if d != nil {
+			c.info(fmt.Sprintf("Not updating worktree '%s', branch '%s' has diverged from its upstream (%s)", w.Path, w.Branch, d))
+			continue
+		}
+		err = wc.echoEval(`git merge --ff-only @{u}`)
+		if err != nil {
+			return fmt.Errorf("error fast-forwarding worktree '%s': %w", w.Path, err)
+		}
+	}
+	return nil
+}
+
+// repoWorktree is a linked worktree of a repo in the workspace
+type repoWorktree struct {
+	Repo string `json:"repo"`
+	gitWorktree
+}
+
+// collectWorktrees finds the linked worktrees of every repo under baseDir concurrently
+func collectWorktrees(baseDir string) []repoWorktree {
+	mu := sync.Mutex{}
+	worktrees := []repoWorktree{}
+	p := pool.New().WithMaxGoroutines(SyncWorkerPoolSize)
+	forEachGitDirIn(baseDir, func(relativeDir string) {
+		dir := filepath.Join(baseDir, relativeDir)
+		if isWorktree(dir) {
+			return // listed with its repo
+		}
+		p.Go(func() {
+			ws, err := listWorktrees(dir)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, w := range ws {
+				if !w.Main {
+					w.Path = relativeToWorkspace(w.Path)
+					worktrees = append(worktrees, repoWorktree{Repo: relativeToWorkspace(dir), gitWorktree: w})
+				}
+			}
+		})
+	})
+	p.Wait()
+
+	slices.SortStableFunc(worktrees, func(a, b repoWorktree) int {
+		if c := strings.Compare(a.Repo, b.Repo); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Path, b.Path)
+	})
+	return worktrees
+}
+
+func printWorktreesTable(worktrees []repoWorktree) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REPO\tBRANCH\tPATH")
+	for _, wt := range worktrees {
+		branch := wt.Branch
+		if branch == "" {
+			branch = "(detached)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", wt.Repo, branch, wt.Path)
+	}
+	w.Flush()
+}
+
+func init() {
+	var jsonFlag bool
+	var force bool
+
+	echo := func(cmd, dir string) { color.Cyan(" + %s", cmd) }
+
+	var cmdWorktree = &cobra.Command{
+		Use:   "worktree",
+		Short: "Manage extra worktrees of repositories",
+		Long: `Manage extra worktrees of repositories, to have more than one branch of a
+repository checked out at once.
+
+Worktrees are created in $ORGIT_WORKSPACE/.worktrees at the path of the
+repository followed by @BRANCH, e.g. .worktrees/github.com/org/repo@feature.
+'orgit sync' fast-forwards the branches checked out in worktrees when they have
+no uncommitted changes, and never tidies worktrees away.
+`,
+	}
+
+	var cmdWorktreeAdd = &cobra.Command{
+		Use:   "add REPO BRANCH",
+		Short: "Check out a branch of a repository in a new worktree",
+		Long: `Check out BRANCH of REPO in a new worktree. A branch that only exists on origin
+is created tracking it, otherwise a new branch is created from the current HEAD
+of REPO.
+
+REPO can be a path relative to the workspace, or the URL of a repo in any form
+accepted by 'orgit get'.
+`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			repoDir := resolveWorkspacePath(getWorkspaceDir(), args[0])
+			if !isGitRepo(repoDir) || isWorktree(repoDir) {
+				cmd.PrintErrf("'%s' is not a git repository in the workspace\n", repoDir)
+				os.Exit(1)
+			}
+
+			lock, err := acquireWorkspaceLock(false, func(msg string) { cmd.PrintErrln(msg) })
+			if err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+			defer lock.Release()
+
+			c := &getCmdContext{WorkingDir: repoDir, Stdout: os.Stdout, Stderr: os.Stderr, CmdEchoFunc: echo}
+			dir, err := c.addWorktree(args[1])
+			if err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+			fmt.Println(dir)
+		},
+	}
+
+	var cmdWorktreeList = &cobra.Command{
+		Use:   "list [DIR]",
+		Short: "List the worktrees of repositories",
+		Long: `List the extra worktrees of each git repository in DIR, or in the workspace path
+if DIR is not specified.
+`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			baseDir := getWorkspaceDir()
+			if len(args) == 1 {
+				baseDir = resolveWorkspacePath(baseDir, args[0])
+			}
+			if !dirExists(baseDir) {
+				cmd.PrintErrf("'%s' does not exist\n", baseDir)
+				os.Exit(1)
+			}
+
+			worktrees := collectWorktrees(baseDir)
+			if jsonFlag {
+				err := printJson(worktrees)
+				if err != nil {
+					cmd.PrintErrln(err)
+					os.Exit(1)
+				}
+				return
+			}
+			printWorktreesTable(worktrees)
+		},
+	}
+
+	var cmdWorktreeRemove = &cobra.Command{
+		Use:   "remove (REPO BRANCH | WORKTREE)",
+		Short: "Remove a worktree",
+		Long: `Remove the worktree of REPO with BRANCH checked out, or the worktree at the path
+WORKTREE. The branch itself is kept. Worktrees with uncommitted changes are only
+removed with --force.
+`,
+		Args: cobra.RangeArgs(1, 2),
+		Run: func(cmd *cobra.Command, args []string) {
+			dir := resolveWorkspacePath(getWorkspaceDir(), args[0])
+			if len(args) == 2 {
+				w, found, err := worktreeWithBranch(dir, args[1])
+				if err != nil {
+					cmd.PrintErrln(err)
+					os.Exit(1)
+				}
+				if !found || w.Main {
+					cmd.PrintErrf("'%s' isn't checked out in a worktree of '%s'\n", args[1], dir)
+					os.Exit(1)
+				}
+				dir = w.Path
+			}
+
+			lock, err := acquireWorkspaceLock(false, func(msg string) { cmd.PrintErrln(msg) })
+			if err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+			defer lock.Release()
+
+			c := &getCmdContext{Stdout: os.Stdout, Stderr: os.Stderr, CmdEchoFunc: echo}
+			err = c.removeWorktree(dir, force)
+			if err != nil {
+				cmd.PrintErrln(err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmdWorktreeList.Flags().BoolVar(&jsonFlag, "json", false, "Output as JSON")
+	cmdWorktreeRemove.Flags().BoolVar(&force, "force", false, "Remove the worktree even if it has uncommitted changes")
+	cmdWorktree.AddCommand(cmdWorktreeAdd, cmdWorktreeList, cmdWorktreeRemove)
+	rootCmd.AddCommand(cmdWorktree)
+}
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseWorktreeList(t *testing.T) {
+	lines := strings.Split(`worktree /ws/github.com/org/repo
+HEAD 1111111111111111111111111111111111111111
+branch refs/heads/main
+
+worktree /ws/.worktrees/github.com/org/repo@feature-x
+HEAD 2222222222222222222222222222222222222222
+branch refs/heads/feature/x
+
+worktree /ws/.worktrees/github.com/org/repo@v1
+HEAD 3333333333333333333333333333333333333333
+detached`, "\n")
+
+	worktrees := parseWorktreeList(lines)
+	if len(worktrees) != 3 {
+		t.Fatalf("expected 3 worktrees, got %d", len(worktrees))
+	}
+	if !worktrees[0].Main || worktrees[0].Branch != "main" {
+		t.Errorf("expected the main worktree on main, got %+v", worktrees[0])
+	}
+	if worktrees[1].Main || worktrees[1].Branch != "feature/x" || worktrees[1].Head != strings.Repeat("2", 40) {
+		t.Errorf("unexpected worktree %+v", worktrees[1])
+	}
+	if worktrees[2].Branch != "" {
+		t.Errorf("expected a detached worktree, got %+v", worktrees[2])
+	}
+}
+
+func TestWorktrees(t *testing.T) {
+	remote, local := newTestRepoWithRemote(t)
+	workspace := filepath.Dir(local)
+	t.Setenv("ORGIT_WORKSPACE", workspace)
+	other := filepath.Join(t.TempDir(), "other")
+	gitInTest(t, filepath.Dir(other), "clone", "--quiet", remote, other)
+
+	pushChange := func(file string) {
+		writeTestFile(t, filepath.Join(other, file), file)
+		gitInTest(t, other, "add", file)
+		gitInTest(t, other, "commit", "--quiet", "-m", file)
+		gitInTest(t, other, "push", "--quiet", "origin", "HEAD")
+	}
+	rev := func(dir, rev string) string {
+		out, err := doExecQuietWithOutput(dir, "git rev-parse "+rev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.TrimSpace(out)
+	}
+
+	gitInTest(t, other, "checkout", "--quiet", "-b", "feature")
+	pushChange("f1")
+	gitInTest(t, local, "fetch", "--quiet")
+
+	c := getCmdContext{
+		Stdout:      io.Discard,
+		Stderr:      io.Discard,
+		CmdEchoFunc: func(cmd, dir string) {},
+		InfoFunc:    func(msg string) {},
+		WorkingDir:  local,
+	}
+	dir, err := c.addWorktree("feature")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir != getWorktreeDir(local, "feature") || !isWorktree(dir) || !isGitRepo(dir) {
+		t.Fatalf("expected a worktree at %s", getWorktreeDir(local, "feature"))
+	}
+	if actual := rev(dir, "--abbrev-ref @{u}"); actual != "origin/feature" {
+		t.Errorf("expected the new branch to track origin/feature, got %s", actual)
+	}
+	if _, err := c.addWorktree("feature"); err == nil {
+		t.Error("expected an error adding a worktree for a branch that's already checked out")
+	}
+
+	worktrees := collectWorktrees(workspace)
+	if len(worktrees) != 1 || worktrees[0].Branch != "feature" || worktrees[0].Repo != "local" {
+		t.Errorf("expected the feature worktree to be listed, got %+v", worktrees)
+	}
+
+	// updating the repo fast-forwards its worktrees, unless they're dirty
+	remoteUrl, _ := url.Parse(remote)
+	pushChange("f2")
+	if err := c.doUpdate(remoteUrl, "main"); err != nil {
+		t.Fatal(err)
+	}
+	if rev(dir, "HEAD") != rev(other, "HEAD") {
+		t.Error("expected the worktree to be fast-forwarded")
+	}
+	pushChange("f3")
+	writeTestFile(t, filepath.Join(dir, "wip"), "wip")
+	if err := c.doUpdate(remoteUrl, "main"); err != nil {
+		t.Fatal(err)
+	}
+	if rev(dir, "HEAD") == rev(other, "HEAD") {
+		t.Error("expected a dirty worktree not to be updated")
+	}
+
+	if err := c.removeWorktree(dir, false); err == nil {
+		t.Error("expected a dirty worktree not to be removed without force")
+	}
+	if err := c.removeWorktree(dir, true); err != nil {
+		t.Fatal(err)
+	}
+	if dirExists(dir) {
+		t.Error("expected the worktree to be removed")
+	}
+	if !c.branchExists("feature") {
+		t.Error("expected the branch to be kept")
+	}
+}
+
+func TestLockedErrorInWorktree(t *testing.T) {
+	_, local := newTestRepoWithRemote(t)
+	worktree := filepath.Join(filepath.Dir(local), "worktree")
+	gitInTest(t, local, "worktree", "add", "--quiet", "-b", "feature", worktree)
+
+	c := getCmdContext{WorkingDir: worktree}
+	if err := c.lockedError(); err != nil {
+		t.Fatalf("Expected the worktree not to be locked, got %s", err)
+	}
+	indexLock := gitPath(worktree, "index.lock")
+	if !strings.HasPrefix(indexLock, filepath.Join(local, ".git", "worktrees")) {
+		t.Errorf("Expected index.lock in the repo's git dir, got %s", indexLock)
+	}
+	writeTestFile(t, indexLock, "")
+	if err := c.lockedError(); err == nil {
+		t.Error("Expected the worktree to be locked")
+	}
+}
@@ -9,6 +9,9 @@ import (
 
 var defaultPrinter = NewPrinter(os.Stderr)
 
+// Stdout serialises output written to os.Stdout
+var Stdout = NewPrinter(os.Stdout)
+
 type Printer struct {
 	w           io.Writer
 	outputMutex sync.Mutex